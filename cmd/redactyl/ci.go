@@ -29,11 +29,15 @@ scan:
   script:
     - go version
     - go build -o bin/redactyl .
-    - ./bin/redactyl scan --json --fail-on medium | tee redactyl-findings.json
+    - ./bin/redactyl scan --no-tui --no-cache --format gitlab-secret-detection --codeclimate gl-code-quality-report.json --fail-on medium > gl-secret-detection-report.json
   artifacts:
     when: always
+    reports:
+      secret_detection: gl-secret-detection-report.json
+      codequality: gl-code-quality-report.json
     paths:
-      - redactyl-findings.json
+      - gl-secret-detection-report.json
+      - gl-code-quality-report.json
 `
 			case "bitbucket":
 				path = "bitbucket-pipelines.yml"
@@ -1,13 +1,25 @@
 package redactyl
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/varalys/redactyl/internal/config"
+	"github.com/varalys/redactyl/internal/engine"
+	"github.com/varalys/redactyl/internal/git"
+	"github.com/varalys/redactyl/internal/hooks"
+	"github.com/varalys/redactyl/internal/report"
+	"github.com/varalys/redactyl/internal/types"
 )
 
+// maxRejectLines caps how many findings a rejected push lists.
+const maxRejectLines = 20
+
 func init() {
 	cmd := &cobra.Command{
 		Use:   "hook",
@@ -15,41 +27,260 @@ func init() {
 	}
 	rootCmd.AddCommand(cmd)
 
+	var hookRoot string
+	cmd.PersistentFlags().StringVar(&hookRoot, "path", ".", "repository to manage or scan")
+
 	install := &cobra.Command{
-		Use:   "install",
-		Short: "Install git hooks",
+		Use:   "install [pre-commit|pre-push]...",
+		Short: "Install git hooks (chains to existing hooks, honours core.hooksPath)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names := append([]string(nil), args...)
+			for _, name := range hooks.Supported {
+				if ok, _ := cmd.Flags().GetBool(name); ok {
+					names = append(names, name)
+				}
+			}
+			if len(names) == 0 {
+				return fmt.Errorf("specify pre-commit and/or pre-push")
+			}
+			dir, err := git.HooksDir(hookRoot)
+			if err != nil {
+				return fmt.Errorf("not a git repository: %w", err)
+			}
+			for _, name := range names {
+				st, err := hooks.Install(dir, name)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Installed %s hook -> %s\n", name, st.Path)
+				if st.Chained != "" {
+					fmt.Printf("  existing hook kept and chained: %s\n", st.Chained)
+				}
+			}
+			return nil
+		},
+	}
+	for _, name := range hooks.Supported {
+		install.Flags().Bool(name, false, "install "+name+" hook")
 	}
 	cmd.AddCommand(install)
 
-	preCommit := &cobra.Command{
-		Use:   "--pre-commit",
-		Short: "Install pre-commit hook for staged scan",
+	uninstall := &cobra.Command{
+		Use:   "uninstall [pre-commit|pre-push]...",
+		Short: "Remove redactyl hooks and restore chained hooks (all by default)",
+		RunE: func(_ *cobra.Command, args []string) error {
+			names := args
+			if len(names) == 0 {
+				names = hooks.Supported
+			}
+			dir, err := git.HooksDir(hookRoot)
+			if err != nil {
+				return fmt.Errorf("not a git repository: %w", err)
+			}
+			for _, name := range names {
+				before, err := hooks.Status(dir, name)
+				if err != nil {
+					return err
+				}
+				st, err := hooks.Uninstall(dir, name)
+				if errors.Is(err, hooks.ErrNotManaged) && len(args) == 0 {
+					continue // leave other tools' hooks alone when removing all
+				}
+				if err != nil {
+					return err
+				}
+				switch {
+				case st.Restored:
+					fmt.Printf("Removed %s hook; restored the original %s\n", name, st.Path)
+				case before.Managed:
+					fmt.Printf("Removed %s hook: %s\n", name, st.Path)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.AddCommand(uninstall)
+
+	status := &cobra.Command{
+		Use:   "status",
+		Short: "Show which hooks redactyl manages",
 		RunE: func(_ *cobra.Command, _ []string) error {
-			hookDir := filepath.Join(".git", "hooks")
-			if _, err := os.Stat(hookDir); os.IsNotExist(err) {
-				return fmt.Errorf("not a git repository (missing .git/hooks)")
+			dir, err := git.HooksDir(hookRoot)
+			if err != nil {
+				return fmt.Errorf("not a git repository: %w", err)
+			}
+			fmt.Printf("Hooks directory: %s\n", dir)
+			for _, name := range hooks.Supported {
+				st, err := hooks.Status(dir, name)
+				if err != nil {
+					return err
+				}
+				state := "not installed"
+				switch {
+				case st.Managed:
+					state = "installed"
+				case st.Exists:
+					state = "other hook present (not managed by redactyl)"
+				}
+				if st.Chained != "" {
+					state += ", chains to " + filepath.Base(st.Chained)
+				}
+				fmt.Printf("  %-10s %s\n", name, state)
+			}
+			return nil
+		},
+	}
+	cmd.AddCommand(status)
+
+	prePush := &cobra.Command{
+		Use:   "pre-push [remote] [url]",
+		Short: "Run as a pre-push hook (scans only the commits being pushed)",
+		Args:  cobra.MaximumNArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			remote := ""
+			if len(args) > 0 {
+				remote = args[0]
 			}
-			hookPath := filepath.Join(hookDir, "pre-commit")
-			content := "#!/bin/sh\n\nredactyl scan --staged\n"
-			if err := os.WriteFile(hookPath, []byte(content), 0755); err != nil {
+			blocked, err := runPrePush(hookRoot, remote, os.Stdin, os.Stderr, flagFailOn)
+			if err != nil {
 				return err
 			}
-			fmt.Println("Installed pre-commit hook -> .git/hooks/pre-commit")
+			if blocked {
+				os.Exit(1)
+			}
 			return nil
 		},
 	}
-	install.Flags().Bool("pre-commit", false, "install pre-commit hook")
-	install.RunE = func(cmd *cobra.Command, _ []string) error {
-		if ok, _ := cmd.Flags().GetBool("pre-commit"); ok {
-			return preCommit.RunE(nil, nil)
+	cmd.AddCommand(prePush)
+
+	preReceive := &cobra.Command{
+		Use:   "pre-receive",
+		Short: "Run as a server-side pre-receive hook (reads <old> <new> <ref> lines on stdin)",
+		Long: `Scan only the objects a push introduces and reject it when secrets are found.
+
+Install on the server as hooks/pre-receive in the bare repository:
+
+  #!/bin/sh
+  exec redactyl hook pre-receive`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			blocked, err := runPreReceive(hookRoot, os.Stdin, os.Stderr, flagFailOn)
+			if err != nil {
+				return err
+			}
+			if blocked {
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+	cmd.AddCommand(preReceive)
+}
+
+// runPreReceive scans the commits, files, messages and tags introduced by the
+// ref updates in in, which need not be reachable from any ref yet, and
+// writes a rejection message to out. It reports whether the push should be
+// blocked.
+func runPreReceive(root string, in io.Reader, out io.Writer, failOn string) (bool, error) {
+	updates, err := git.ParseRefUpdates(in)
+	if err != nil {
+		return false, err
+	}
+	tips := pushedTips(updates)
+	if len(tips) == 0 {
+		return false, nil
+	}
+	return checkPush(engine.Config{Root: root, Revisions: tips, ExcludeExisting: true}, out, failOn)
+}
+
+// runPrePush scans the commits a `git push` to remote would send: those
+// reachable from the pushed local objects but not from what the remote
+// already has. Input is the pre-push stdin; under the pre-commit framework,
+// which consumes stdin, the PRE_COMMIT_* variables are used instead.
+func runPrePush(root, remote string, in io.Reader, out io.Writer, failOn string) (bool, error) {
+	updates, err := git.ParsePrePushInput(in)
+	if err != nil {
+		return false, err
+	}
+	if len(updates) == 0 && os.Getenv("PRE_COMMIT_TO_REF") != "" {
+		updates = []git.RefUpdate{{Old: os.Getenv("PRE_COMMIT_FROM_REF"), New: os.Getenv("PRE_COMMIT_TO_REF")}}
+		if remote == "" {
+			remote = os.Getenv("PRE_COMMIT_REMOTE_NAME")
+		}
+	}
+	tips := pushedTips(updates)
+	if len(tips) == 0 {
+		return false, nil
+	}
+	var exclude []string
+	for _, u := range updates {
+		if u.Old != "" && !u.Created() && git.HasCommit(root, u.Old) {
+			exclude = append(exclude, u.Old)
 		}
-		return fmt.Errorf("specify --pre-commit")
+	}
+	cfg := engine.Config{Root: root, Revisions: tips, ExcludeRevisions: exclude}
+	if remote != "" && !strings.Contains(remote, "/") && !strings.Contains(remote, ":") {
+		cfg.ExcludeRemote = remote // a configured remote name rather than a URL
+	}
+	return checkPush(cfg, out, failOn)
+}
+
+// pushedTips returns the new objects of non-deleting updates.
+func pushedTips(updates []git.RefUpdate) []string {
+	var tips []string
+	seen := map[string]bool{}
+	for _, u := range updates {
+		if u.Deleted() || seen[u.New] {
+			continue
+		}
+		seen[u.New] = true
+		tips = append(tips, u.New)
+	}
+	return tips
+}
+
+// checkPush runs a push scan with cfg's revision selection and the global
+// and repository config, then writes a rejection listing blocking findings
+// (never their values) to out. It reports whether the push should be
+// blocked.
+func checkPush(cfg engine.Config, out io.Writer, failOn string) (bool, error) {
+	var gcfg, lcfg config.FileConfig
+	if c, err := config.LoadGlobal(); err == nil {
+		gcfg = c
+	}
+	if c, err := config.LoadLocal(cfg.Root); err == nil {
+		lcfg = c
+	}
+	cfg.IncludeGlobs = pickString("", lcfg.Include, gcfg.Include)
+	cfg.ExcludeGlobs = pickString("", lcfg.Exclude, gcfg.Exclude)
+	cfg.MaxBytes = pickInt64(1<<20, lcfg.MaxBytes, gcfg.MaxBytes)
+	cfg.Threads = pickInt(flagThreads, lcfg.Threads, gcfg.Threads)
+	cfg.EnableDetectors = pickString("", lcfg.Enable, gcfg.Enable)
+	cfg.DisableDetectors = pickString("", lcfg.Disable, gcfg.Disable)
+	cfg.MinConfidence = pickFloat(flagMinConfidence, lcfg.MinConfidence, gcfg.MinConfidence)
+	cfg.NoCache = true
+	cfg.GitleaksConfig = mergeGitleaksConfig(gcfg, lcfg)
+	res, err := engine.ScanWithStats(cfg)
+	if err != nil {
+		return false, err
 	}
 
-	installPre := &cobra.Command{
-		Use:   "pre-commit",
-		Short: "Install pre-commit hook for staged scan",
-		RunE:  preCommit.RunE,
+	var blocking []types.Finding
+	for _, f := range res.Findings {
+		if report.ShouldFail([]types.Finding{f}, failOn) {
+			blocking = append(blocking, f)
+		}
+	}
+	if len(blocking) == 0 {
+		return false, nil
+	}
+	_, _ = fmt.Fprintf(out, "redactyl: push rejected: %d secret(s) found in pushed commits\n", len(blocking))
+	for i, f := range blocking {
+		if i == maxRejectLines {
+			_, _ = fmt.Fprintf(out, "  ... and %d more\n", len(blocking)-i)
+			break
+		}
+		_, _ = fmt.Fprintf(out, "  %s  %s:%d  %s\n", short(f.Metadata["commit"]), f.Path, f.Line, f.Detector)
 	}
-	install.AddCommand(installPre)
+	_, _ = fmt.Fprintln(out, "Remove the secrets from these commits (e.g. git commit --amend or git rebase -i), rotate them, and push again.")
+	return true, nil
 }
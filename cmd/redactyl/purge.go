@@ -53,6 +53,7 @@ func init() {
 				fmt.Println("History rewritten. You likely need to force-push:")
 				fmt.Println("  git push --force --all && git push --force --tags")
 				fmt.Printf("A backup branch was created: %s\n", backup)
+				printPruneHint(backup)
 			}
 			if summary != "" {
 				_ = writePurgeSummary(summary, map[string]any{
@@ -117,6 +118,7 @@ func init() {
 				fmt.Println("History rewritten. You likely need to force-push:")
 				fmt.Println("  git push --force --all && git push --force --tags")
 				fmt.Printf("A backup branch was created: %s\n", backupPat)
+				printPruneHint(backupPat)
 			}
 			if summaryPat != "" {
 				_ = writePurgeSummary(summaryPat, map[string]any{
@@ -180,6 +182,7 @@ func init() {
 				fmt.Println("History rewritten. You likely need to force-push:")
 				fmt.Println("  git push --force --all && git push --force --tags")
 				fmt.Printf("A backup branch was created: %s\n", backupRepl)
+				printPruneHint(backupRepl)
 			}
 			if summaryRepl != "" {
 				_ = writePurgeSummary(summaryRepl, map[string]any{
@@ -202,6 +205,16 @@ func init() {
 	purge.AddCommand(replaceCmd)
 }
 
+// printPruneHint explains that rewritten-away objects stay in the repository
+// while the backup branch, stashes or reflogs still reference them.
+func printPruneHint(backup string) {
+	fmt.Println("The old commits stay in this repository until nothing references them and git gc prunes them.")
+	fmt.Println("After verifying the rewrite, delete the backup, drop stashes that hold the secret, and prune:")
+	fmt.Printf("  git branch -D %s\n", backup)
+	fmt.Println("  git reflog expire --expire=now --all && git gc --prune=now")
+	fmt.Println("Confirm nothing is left with: redactyl scan --git-forensics")
+}
+
 func writePurgeSummary(path string, data map[string]any) error {
 	f, err := os.Create(path)
 	if err != nil {
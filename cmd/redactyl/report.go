@@ -0,0 +1,184 @@
+package redactyl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/varalys/redactyl/internal/audit"
+	"github.com/varalys/redactyl/internal/cache"
+	"github.com/varalys/redactyl/internal/config"
+	"github.com/varalys/redactyl/internal/engine"
+	"github.com/varalys/redactyl/internal/report"
+	"github.com/varalys/redactyl/internal/types"
+)
+
+// reportFormats lists the values `redactyl report --format` accepts.
+var reportFormats = []string{"table", "text", "json", "sarif", "html"}
+
+// storedResults is a previous scan loaded by `redactyl report`.
+type storedResults struct {
+	Findings     []types.Finding
+	Timestamp    time.Time
+	FilesScanned int
+	Duration     time.Duration
+	Redacted     bool // matches were not stored (audit log without raw data)
+}
+
+func init() {
+	var from, format, output, root, enable, disable string
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Re-render stored scan results without rescanning",
+		Long: `Render the results of an earlier scan in another format, applying the
+current baseline (redactyl.baseline.json) and detector/confidence filters.
+
+--from selects the results:
+  last             the last scan of --path (default)
+  <file>           a JSON report (scan --json or --json-extended) or SARIF log
+  audit:<scan_id>  a scan recorded in the audit log (see the TUI audit view)`,
+		Example: `  redactyl scan --no-tui --json > findings.json
+  redactyl report --format sarif > redactyl.sarif
+  redactyl report --format html -o report.html
+  redactyl report --from findings.json --format table --disable generic-api-key`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if !slices.Contains(reportFormats, format) {
+				return fmt.Errorf("unknown --format %q (supported: %s)", format, strings.Join(reportFormats, ", "))
+			}
+			abs, _ := filepath.Abs(root)
+			stored, err := loadStoredResults(from, abs)
+			if err != nil {
+				return err
+			}
+			if stored.Redacted {
+				_, _ = fmt.Fprintln(os.Stderr, "note: the audit log stores fingerprints, not secret values; matches show as [REDACTED]")
+			}
+
+			var gcfg, lcfg config.FileConfig
+			if c, err := config.LoadGlobal(); err == nil {
+				gcfg = c
+			}
+			if c, err := config.LoadLocal(abs); err == nil {
+				lcfg = c
+			}
+			all := engine.FilterFindings(stored.Findings,
+				pickFloat(flagMinConfidence, lcfg.MinConfidence, gcfg.MinConfidence),
+				pickString(enable, lcfg.Enable, gcfg.Enable),
+				pickString(disable, lcfg.Disable, gcfg.Disable))
+			baseline, baselineErr := report.LoadBaseline("redactyl.baseline.json")
+			findings := report.FilterNewFindings(all, baseline)
+			if findings == nil {
+				findings = []types.Finding{}
+			}
+
+			var buf bytes.Buffer
+			res := engine.Result{Findings: findings, FilesScanned: stored.FilesScanned, Duration: stored.Duration}
+			switch format {
+			case "json":
+				enc := json.NewEncoder(&buf)
+				enc.SetIndent("", "  ")
+				err = enc.Encode(findings)
+			case "sarif":
+				// As with scan --sarif, a baseline keeps baselined findings
+				// as unchanged results.
+				sarifFindings, opts := findings, report.SARIFOptions{}
+				if baselineErr == nil {
+					sarifFindings, opts.Baseline = all, &baseline
+				}
+				err = report.WriteSARIFWithOptions(&buf, sarifFindings, opts)
+			case "html":
+				err = report.WriteHTML(&buf, res, report.HTMLOptions{Target: abs, Version: version, Generated: stored.Timestamp})
+			case "text":
+				report.PrintText(&buf, findings, reportPrintOptions(res, len(all), output))
+			default:
+				report.PrintTable(&buf, findings, reportPrintOptions(res, len(all), output))
+			}
+			if err != nil {
+				return fmt.Errorf("%s report: %w", format, err)
+			}
+			return writeReportOutput(output, buf.Bytes())
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "last", "results to render: last, a JSON/SARIF file, or audit:<scan_id>")
+	cmd.Flags().StringVar(&format, "format", "table", "output format: "+strings.Join(reportFormats, "|"))
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write to this file instead of stdout")
+	cmd.Flags().StringVarP(&root, "path", "p", ".", "repository whose last scan and audit log to read")
+	cmd.Flags().StringVar(&enable, "enable", "", "only show these detectors (comma-separated IDs)")
+	cmd.Flags().StringVar(&disable, "disable", "", "hide these detectors (comma-separated IDs)")
+	rootCmd.AddCommand(cmd)
+}
+
+// loadStoredResults loads the results named by --from.
+func loadStoredResults(from, root string) (storedResults, error) {
+	switch {
+	case from == "" || from == "last":
+		cached, err := cache.LoadResults(root)
+		if err != nil {
+			return storedResults{}, fmt.Errorf("no cached results found: %w\nRun 'redactyl scan' first", err)
+		}
+		return storedResults{Findings: cached.Findings, Timestamp: cached.Timestamp}, nil
+	case strings.HasPrefix(from, "audit:"):
+		id := strings.TrimPrefix(from, "audit:")
+		records, err := audit.NewAuditLog(root).LoadHistory()
+		if err != nil {
+			return storedResults{}, err
+		}
+		for _, r := range records {
+			if r.ScanID != id {
+				continue
+			}
+			d, _ := time.ParseDuration(r.Duration)
+			out := storedResults{Findings: r.AllFindings, Timestamp: r.Timestamp, FilesScanned: r.FilesScanned, Duration: d}
+			for _, f := range r.AllFindings {
+				if f.Match == "[REDACTED]" {
+					out.Redacted = true
+					break
+				}
+			}
+			return out, nil
+		}
+		return storedResults{}, fmt.Errorf("scan %q not found in the audit log", id)
+	}
+	f, err := os.Open(from)
+	if err != nil {
+		return storedResults{}, err
+	}
+	defer f.Close()
+	findings, err := report.ReadFindings(f)
+	if err != nil {
+		return storedResults{}, fmt.Errorf("%s: %w", from, err)
+	}
+	var ts time.Time
+	if st, err := f.Stat(); err == nil {
+		ts = st.ModTime()
+	}
+	return storedResults{Findings: findings, Timestamp: ts}, nil
+}
+
+func reportPrintOptions(res engine.Result, total int, output string) report.PrintOptions {
+	return report.PrintOptions{
+		NoColor:       flagNoColor || output != "" || !isTerminal(os.Stdout),
+		Duration:      res.Duration,
+		FilesScanned:  res.FilesScanned,
+		TotalFindings: total,
+	}
+}
+
+func writeReportOutput(output string, data []byte) error {
+	if output == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "Report written to %s\n", output)
+	return nil
+}
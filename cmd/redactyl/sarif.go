@@ -1,10 +1,15 @@
 package redactyl
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/varalys/redactyl/internal/report"
+	"github.com/varalys/redactyl/internal/types"
 )
 
 func init() {
@@ -36,4 +41,152 @@ func init() {
 		},
 	}
 	cmd.AddCommand(view)
+
+	merge := &cobra.Command{
+		Use:   "merge <file>...",
+		Short: "Combine SARIF logs, de-duplicating runs, rules and results",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			var inputs []io.Reader
+			for _, p := range args {
+				b, err := readInput(p)
+				if err != nil {
+					return err
+				}
+				inputs = append(inputs, bytes.NewReader(b))
+			}
+			return report.MergeSARIF(os.Stdout, inputs...)
+		},
+	}
+	cmd.AddCommand(merge)
+
+	diff := &cobra.Command{
+		Use:   "diff <old> <new>",
+		Short: "List results that are new or fixed between two SARIF or JSON reports",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			prev, err := readFindingsFile(args[0])
+			if err != nil {
+				return err
+			}
+			next, err := readFindingsFile(args[1])
+			if err != nil {
+				return err
+			}
+			added, fixed := report.DiffFindings(prev, next)
+			if flagJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(struct {
+					New   []types.Finding `json:"new"`
+					Fixed []types.Finding `json:"fixed"`
+				}{New: nonNilFindings(added), Fixed: nonNilFindings(fixed)})
+			}
+			printDiffSection("New", added)
+			printDiffSection("Fixed", fixed)
+			return nil
+		},
+	}
+	cmd.AddCommand(diff)
+
+	var filterSeverity string
+	var filterPaths []string
+	filter := &cobra.Command{
+		Use:   "filter <file>",
+		Short: "Keep only SARIF results matching a minimum severity or path globs",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			switch filterSeverity {
+			case "", "low", "medium", "high":
+			default:
+				return fmt.Errorf("unknown --min-severity %q. Supported: low, medium, high", filterSeverity)
+			}
+			b, err := readInput(args[0])
+			if err != nil {
+				return err
+			}
+			return report.FilterSARIF(os.Stdout, bytes.NewReader(b), report.SARIFFilter{MinSeverity: filterSeverity, Paths: filterPaths})
+		},
+	}
+	filter.Flags().StringVar(&filterSeverity, "min-severity", "", "drop results below low|medium|high")
+	filter.Flags().StringArrayVar(&filterPaths, "path", nil, "keep results whose path (or base name) matches this glob; repeatable")
+	cmd.AddCommand(filter)
+
+	to := &cobra.Command{
+		Use:       "to json|csv|markdown <file>",
+		Short:     "Convert a SARIF log to redactyl JSON, CSV or a Markdown table",
+		Args:      cobra.ExactArgs(2),
+		ValidArgs: []string{"json", "csv", "markdown"},
+		RunE: func(_ *cobra.Command, args []string) error {
+			findings, err := readFindingsFile(args[1])
+			if err != nil {
+				return err
+			}
+			switch args[0] {
+			case "json":
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(nonNilFindings(findings))
+			case "csv":
+				return report.WriteCSV(os.Stdout, findings)
+			case "markdown", "md":
+				return report.WriteMarkdownTable(os.Stdout, findings)
+			}
+			return fmt.Errorf("unknown format %q. Supported: json, csv, markdown", args[0])
+		},
+	}
+	cmd.AddCommand(to)
+
+	from := &cobra.Command{
+		Use:   "from <findings.json>",
+		Short: "Convert redactyl JSON findings (scan --json) to SARIF",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			b, err := readInput(args[0])
+			if err != nil {
+				return err
+			}
+			findings, err := report.ReadFindingsJSON(bytes.NewReader(b))
+			if err != nil {
+				return fmt.Errorf("%s: %w", args[0], err)
+			}
+			return report.WriteSARIF(os.Stdout, findings)
+		},
+	}
+	cmd.AddCommand(from)
+}
+
+// readInput reads a file, or stdin when path is "-".
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// readFindingsFile loads findings from a SARIF log or redactyl JSON report.
+func readFindingsFile(path string) ([]types.Finding, error) {
+	b, err := readInput(path)
+	if err != nil {
+		return nil, err
+	}
+	findings, err := report.ReadFindings(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return findings, nil
+}
+
+func nonNilFindings(fs []types.Finding) []types.Finding {
+	if fs == nil {
+		return []types.Finding{}
+	}
+	return fs
+}
+
+func printDiffSection(title string, findings []types.Finding) {
+	fmt.Printf("%s (%d):\n", title, len(findings))
+	for _, f := range findings {
+		fmt.Printf("  %s:%d  %s  %s\n", f.Path, f.Line, f.Detector, f.Severity)
+	}
 }
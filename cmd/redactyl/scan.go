@@ -1,10 +1,14 @@
 package redactyl
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,6 +19,7 @@ import (
 	"github.com/varalys/redactyl/internal/cache"
 	"github.com/varalys/redactyl/internal/config"
 	"github.com/varalys/redactyl/internal/engine"
+	"github.com/varalys/redactyl/internal/git"
 	"github.com/varalys/redactyl/internal/report"
 	"github.com/varalys/redactyl/internal/tui"
 	"github.com/varalys/redactyl/internal/types"
@@ -25,7 +30,21 @@ var (
 	flagPath         string
 	flagStaged       bool
 	flagHistory      int
+	flagAllHistory   bool
 	flagBase         string
+	flagRange        string
+	flagSince        string
+	flagUntil        string
+	flagAuthor       string
+	flagBranches     []string
+	flagRef          string
+	flagForensics    bool
+	flagRepo         string
+	flagCloneDepth   int
+	flagSingleBranch bool
+	flagCloneBranch  string
+	flagSubmodules   bool
+	flagLFS          bool
 	flagInclude      string
 	flagExclude      string
 	flagMaxBytes     int64
@@ -53,6 +72,14 @@ var (
 	flagNoTUI        bool
 	flagViewLast     bool
 	flagDemo         bool
+	flagHTML         string
+	flagHTMLUnmasked bool
+	flagFormat       string
+	flagJUnit        string
+	flagCodeClimate  string
+	flagNDJSON       bool
+	flagTemplate     string
+	flagCoverage     bool
 
 	flagRegistryImages []string
 )
@@ -77,7 +104,21 @@ func init() {
 	_ = cmd.Flags().MarkDeprecated("interactive", "TUI is now the default mode. Use --no-tui to disable it.")
 	cmd.Flags().BoolVar(&flagStaged, "staged", false, "scan staged changes")
 	cmd.Flags().IntVar(&flagHistory, "history", 0, "scan last N commits (0=off)")
+	cmd.Flags().BoolVar(&flagAllHistory, "all-history", false, "scan every commit on all branches and tags (each unique blob once)")
 	cmd.Flags().StringVar(&flagBase, "base", "", "scan diff vs base branch (e.g. main)")
+	cmd.Flags().StringVar(&flagRange, "range", "", "scan commits in a revision range (e.g. v1.0..v2.0)")
+	cmd.Flags().StringVar(&flagSince, "since", "", "scan commits more recent than a date (e.g. 2024-01-01, \"2 weeks ago\")")
+	cmd.Flags().StringVar(&flagUntil, "until", "", "scan commits older than a date")
+	cmd.Flags().StringVar(&flagAuthor, "author", "", "scan commits whose author matches this pattern")
+	cmd.Flags().StringArrayVar(&flagBranches, "branch", nil, "scan commits on branches matching a name or glob (repeatable, e.g. release/*)")
+	cmd.Flags().BoolVar(&flagForensics, "git-forensics", false, "scan stash entries, reflog-only commits and unreachable objects")
+	cmd.Flags().StringVar(&flagRef, "ref", "", "scan the tree of a tag, commit or tree without checking it out (works in bare repos)")
+	cmd.Flags().StringVar(&flagRepo, "repo", "", "scan the history and HEAD of a repository URL or path via a temporary mirror clone")
+	cmd.Flags().IntVar(&flagCloneDepth, "clone-depth", 0, "with --repo, clone only the most recent N commits of each ref (0=full history)")
+	cmd.Flags().BoolVar(&flagSingleBranch, "single-branch", false, "with --repo, clone only the default branch")
+	cmd.Flags().StringVar(&flagCloneBranch, "clone-branch", "", "with --repo, clone only this branch")
+	cmd.Flags().BoolVar(&flagSubmodules, "submodules", false, "also scan initialised submodules, recursively")
+	cmd.Flags().BoolVar(&flagLFS, "lfs", false, "scan Git LFS objects from the local store instead of their pointer files")
 	cmd.Flags().StringVar(&flagInclude, "include", "", "comma-separated include globs")
 	cmd.Flags().StringVar(&flagExclude, "exclude", "", "comma-separated exclude globs")
 	cmd.Flags().Int64Var(&flagMaxBytes, "max-bytes", 1<<20, "skip files larger than this")
@@ -92,7 +133,7 @@ func init() {
 	// deep scanning flags
 	cmd.Flags().BoolVar(&flagArchives, "archives", false, "enable deep scanning of archives (zip/tar/gz)")
 	cmd.Flags().BoolVar(&flagContainers, "containers", false, "enable deep scanning of container tarballs (Docker save)")
-	cmd.Flags().BoolVar(&flagIaC, "iac", false, "enable scanning IaC hotspots (tfstate, kubeconfigs)")
+	cmd.Flags().BoolVar(&flagIaC, "iac", false, "enable scanning IaC: tfstate, kubeconfigs, Terraform HCL/tfvars/plan JSON, CloudFormation, ARM/Bicep, Pulumi, Dockerfile/compose")
 	cmd.Flags().BoolVar(&flagHelm, "helm", false, "enable scanning Helm charts (.tgz archives and directories)")
 	cmd.Flags().BoolVar(&flagK8s, "k8s", false, "enable scanning Kubernetes manifests (YAML files)")
 	cmd.Flags().StringArrayVar(&flagRegistryImages, "registry", nil, "scan remote container registry image (e.g. gcr.io/project/image:tag)")
@@ -102,6 +143,14 @@ func init() {
 	cmd.Flags().DurationVar(&flagScanTimeBudget, "scan-time-budget", 10*time.Second, "time budget per artifact (e.g., 10s)")
 	cmd.Flags().DurationVar(&flagGlobalArtifactBudget, "global-artifact-budget", 0, "optional global time budget across all artifacts (e.g., 10s)")
 	cmd.Flags().BoolVar(&flagJSONExtended, "json-extended", false, "when used with --json, include artifact stats in the JSON object; adds a schema_version field")
+	cmd.Flags().StringVar(&flagFormat, "format", "", "output format: "+strings.Join(scanFormats, "|"))
+	cmd.Flags().BoolVar(&flagNDJSON, "ndjson", false, "stream findings as newline-delimited JSON while scanning, then a final stats record")
+	cmd.Flags().StringVar(&flagTemplate, "template", "", "with --format template, a text/template file or built-in name ("+strings.Join(report.BuiltinTemplateNames(), ", ")+")")
+	cmd.Flags().BoolVar(&flagCoverage, "coverage", false, "report skipped paths with their reasons and the bytes scanned per artifact type (on stderr)")
+	cmd.Flags().StringVar(&flagJUnit, "junit", "", "also write JUnit XML to this file (one testcase per scanned file or artifact)")
+	cmd.Flags().StringVar(&flagCodeClimate, "codeclimate", "", "also write a CodeClimate (GitLab code quality) report to this file")
+	cmd.Flags().StringVar(&flagHTML, "html", "", "also write a self-contained HTML report to this file")
+	cmd.Flags().BoolVar(&flagHTMLUnmasked, "html-unmasked", false, "with --html, show full secret values instead of masking them")
 }
 
 func resolveBudgets(flagBudget time.Duration, lcfg, gcfg config.FileConfig, flagGlobalBudget time.Duration) (time.Duration, time.Duration) {
@@ -171,6 +220,16 @@ func mergeGitleaksConfig(gcfg, lcfg config.FileConfig) config.GitleaksConfig {
 
 func runScan(cmd *cobra.Command, _ []string) error {
 	abs, _ := filepath.Abs(flagPath)
+	if err := applyFormat(); err != nil {
+		return err
+	}
+	var tmplName, tmplText string
+	if flagFormat == formatTemplate {
+		var err error
+		if tmplName, tmplText, err = loadTemplate(flagTemplate); err != nil {
+			return err
+		}
+	}
 
 	if flagViewLast {
 		results, err := cache.LoadResults(abs)
@@ -202,14 +261,44 @@ func runScan(cmd *cobra.Command, _ []string) error {
 
 	budget, globalBudget := resolveBudgets(flagScanTimeBudget, lcfg, gcfg, flagGlobalArtifactBudget)
 
+	// With --repo, config, baseline, cache and audit log still come from
+	// --path; only the scanned tree is the temporary clone.
+	root, target := abs, abs
+	cleanup := func() {}
+	if flagRepo != "" {
+		if !structuredOutput() {
+			_, _ = fmt.Fprintf(os.Stderr, "Cloning %s...\n", git.RedactURL(flagRepo))
+		}
+		dir, remove, err := cloneRepo(flagRepo, git.CloneOptions{Depth: flagCloneDepth, SingleBranch: flagSingleBranch, Branch: flagCloneBranch})
+		if err != nil {
+			return err
+		}
+		root, target, cleanup = dir, git.RedactURL(flagRepo), remove
+	} else if flagCloneDepth != 0 || flagSingleBranch || flagCloneBranch != "" {
+		return fmt.Errorf("--clone-depth, --single-branch and --clone-branch require --repo")
+	}
+	defer cleanup()
+
 	cfg := engine.Config{
-		Root:                 abs,
+		Root:                 root,
 		IncludeGlobs:         pickString(flagInclude, lcfg.Include, gcfg.Include),
 		ExcludeGlobs:         pickString(flagExclude, lcfg.Exclude, gcfg.Exclude),
 		MaxBytes:             pickInt64(flagMaxBytes, lcfg.MaxBytes, gcfg.MaxBytes),
 		ScanStaged:           flagStaged,
 		HistoryCommits:       flagHistory,
+		AllHistory:           flagAllHistory,
 		BaseBranch:           flagBase,
+		RevRange:             flagRange,
+		Since:                flagSince,
+		Until:                flagUntil,
+		Author:               flagAuthor,
+		Branches:             flagBranches,
+		Ref:                  flagRef,
+		GitForensics:         flagForensics,
+		Submodules:           flagSubmodules,
+		LFS:                  flagLFS,
+		RecordScanned:        flagJUnit != "",
+		RecordCoverage:       flagCoverage,
 		Threads:              pickInt(flagThreads, lcfg.Threads, gcfg.Threads),
 		EnableDetectors:      pickString(flagEnable, lcfg.Enable, gcfg.Enable),
 		DisableDetectors:     pickString(flagDisable, lcfg.Disable, gcfg.Disable),
@@ -231,8 +320,17 @@ func runScan(cmd *cobra.Command, _ []string) error {
 		GlobalArtifactBudget: globalBudget,
 		GitleaksConfig:       mergeGitleaksConfig(gcfg, lcfg),
 	}
+	if flagRepo != "" {
+		// Walk every ref unless the commits were narrowed explicitly.
+		if cfg.RevRange == "" && len(cfg.Branches) == 0 && cfg.HistoryCommits == 0 {
+			cfg.AllHistory = true
+		}
+		if cfg.Ref == "" && git.HasCommit(root, "HEAD") {
+			cfg.Ref = "HEAD"
+		}
+	}
 
-	if !flagJSON && !flagSARIF {
+	if !structuredOutput() {
 		if !flagNoUpdateCheck {
 			if latest, newer, _ := update.Check(version, false); newer && latest != "" {
 				_, _ = fmt.Fprintf(os.Stderr, "(new version available: v%s)  run 'redactyl update' to upgrade\n", latest)
@@ -245,12 +343,12 @@ func runScan(cmd *cobra.Command, _ []string) error {
 				return nil
 			}
 		}
-		_, _ = fmt.Fprintf(os.Stderr, "Scanning %s with %d detectors...\n", abs, len(engine.DetectorIDs()))
+		_, _ = fmt.Fprintf(os.Stderr, "Scanning %s with %d detectors...\n", target, len(engine.DetectorIDs()))
 	}
 
 	total, _ := engine.CountTargets(cfg)
 	progressed := 0
-	if total > 0 && !flagJSON && !flagSARIF {
+	if total > 0 && !structuredOutput() {
 		cfg.Progress = func() {
 			progressed++
 			if progressed%10 == 0 || progressed == total {
@@ -259,15 +357,26 @@ func runScan(cmd *cobra.Command, _ []string) error {
 			}
 		}
 	}
+	baseline, baselineErr := report.LoadBaseline("redactyl.baseline.json")
+	streamed := 0
+	if flagNDJSON {
+		cfg.OnFinding = func(f types.Finding) {
+			if report.IsBaselined(f, baseline.Items) {
+				return
+			}
+			streamed++
+			_ = report.WriteNDJSONFinding(os.Stdout, f)
+		}
+	}
 	res, err := engine.ScanWithStats(cfg)
 	if err != nil {
 		return fmt.Errorf("scan error: %w", err)
 	}
-	if total > 0 && !flagJSON && !flagSARIF {
+	if total > 0 && !structuredOutput() {
 		_, _ = fmt.Fprintln(os.Stderr)
 	}
+	printMissingLFS(os.Stderr, res.MissingLFS)
 
-	baseline, _ := report.LoadBaseline("redactyl.baseline.json")
 	newFindings := report.FilterNewFindings(res.Findings, baseline)
 	if newFindings == nil {
 		newFindings = []types.Finding{}
@@ -287,7 +396,7 @@ func runScan(cmd *cobra.Command, _ []string) error {
 	auditLog := audit.NewAuditLog(abs)
 	prefs := tui.LoadPrefs()
 	auditRecord := audit.CreateScanRecord(
-		abs,
+		target,
 		auditFindings,
 		newFindings,
 		res.FilesScanned,
@@ -299,7 +408,34 @@ func runScan(cmd *cobra.Command, _ []string) error {
 		_, _ = fmt.Fprintf(os.Stderr, "Warning: failed to write audit log: %v\n", err)
 	}
 
-	useTUI := !flagNoTUI && !flagJSON && !flagSARIF && !flagText
+	if flagHTML != "" {
+		if err := writeHTMLReport(flagHTML, res, newFindings, target); err != nil {
+			return err
+		}
+		if !structuredOutput() {
+			_, _ = fmt.Fprintf(os.Stderr, "HTML report written to %s\n", flagHTML)
+		}
+	}
+
+	if flagCodeClimate != "" {
+		if err := writeCodeClimateReport(flagCodeClimate, newFindings); err != nil {
+			return err
+		}
+		if !structuredOutput() {
+			_, _ = fmt.Fprintf(os.Stderr, "CodeClimate report written to %s\n", flagCodeClimate)
+		}
+	}
+
+	if flagJUnit != "" {
+		if err := writeJUnitReport(flagJUnit, res, newFindings); err != nil {
+			return err
+		}
+		if !structuredOutput() {
+			_, _ = fmt.Fprintf(os.Stderr, "JUnit report written to %s\n", flagJUnit)
+		}
+	}
+
+	useTUI := !flagNoTUI && !structuredOutput() && !flagText && !flagCoverage
 	if useTUI && !isTerminal(os.Stdout) {
 		useTUI = false
 	}
@@ -355,6 +491,43 @@ func runScan(cmd *cobra.Command, _ []string) error {
 	}
 
 	switch {
+	case flagFormat == formatMarkdown:
+		if err := writeMarkdownSummary(root, target, auditFindings, newFindings, baseline); err != nil {
+			return err
+		}
+	case flagFormat == formatTemplate:
+		if err := writeTemplateReport(os.Stdout, tmplName, tmplText, root, target, res, newFindings); err != nil {
+			return err
+		}
+	case flagNDJSON:
+		summary := report.NDJSONSummary{
+			FilesScanned:  res.FilesScanned,
+			Findings:      streamed,
+			TotalFindings: len(res.Findings),
+			DurationMS:    res.Duration.Milliseconds(),
+			ArtifactStats: map[string]int{
+				"bytes":   res.ArtifactStats.AbortedByBytes,
+				"entries": res.ArtifactStats.AbortedByEntries,
+				"depth":   res.ArtifactStats.AbortedByDepth,
+				"time":    res.ArtifactStats.AbortedByTime,
+			},
+			LFSMissing: res.MissingLFS,
+			Coverage:   res.Coverage,
+		}
+		if err := report.WriteNDJSONStats(os.Stdout, summary); err != nil {
+			return err
+		}
+	case flagFormat == formatGitLab:
+		_, commit, _ := git.RepoMetadata(root)
+		end := time.Now()
+		opts := report.GitLabOptions{Version: version, Commit: commit, Start: end.Add(-res.Duration), End: end}
+		if err := report.WriteGitLabSecretDetection(os.Stdout, newFindings, opts); err != nil {
+			return fmt.Errorf("gitlab report error: %w", err)
+		}
+	case flagFormat == formatCodeClimate:
+		if err := report.WriteCodeClimate(os.Stdout, newFindings); err != nil {
+			return fmt.Errorf("codeclimate report error: %w", err)
+		}
 	case flagSARIF:
 		stats := map[string]int{
 			"bytes":   res.ArtifactStats.AbortedByBytes,
@@ -362,7 +535,13 @@ func runScan(cmd *cobra.Command, _ []string) error {
 			"depth":   res.ArtifactStats.AbortedByDepth,
 			"time":    res.ArtifactStats.AbortedByTime,
 		}
-		if err := report.WriteSARIFWithStats(os.Stdout, newFindings, stats); err != nil {
+		// With a baseline, baselined findings are kept and marked unchanged
+		// so code scanning can track them; otherwise only new ones exist.
+		sarifFindings, opts := newFindings, report.SARIFOptions{ArtifactStats: stats}
+		if baselineErr == nil {
+			sarifFindings, opts.Baseline = auditFindings, &baseline
+		}
+		if err := report.WriteSARIFWithOptions(os.Stdout, sarifFindings, opts); err != nil {
 			return fmt.Errorf("sarif error: %w", err)
 		}
 	case flagJSON:
@@ -379,6 +558,12 @@ func runScan(cmd *cobra.Command, _ []string) error {
 					"time":    res.ArtifactStats.AbortedByTime,
 				},
 			}
+			if len(res.MissingLFS) > 0 {
+				payload["lfs_missing"] = res.MissingLFS
+			}
+			if res.Coverage != nil {
+				payload["coverage"] = res.Coverage
+			}
 			if err := enc.Encode(payload); err != nil {
 				return err
 			}
@@ -390,15 +575,7 @@ func runScan(cmd *cobra.Command, _ []string) error {
 	case flagText:
 		report.PrintText(os.Stdout, newFindings, report.PrintOptions{NoColor: flagNoColor, Duration: res.Duration, FilesScanned: res.FilesScanned, TotalFiles: total, TotalFindings: len(res.Findings)})
 		if flagGuide && len(newFindings) > 0 {
-			_, _ = fmt.Fprintln(os.Stderr, "\nSuggested remediation commands:")
-			for _, f := range newFindings {
-				lower := strings.ToLower(f.Path)
-				if strings.HasSuffix(lower, ".env") || strings.Contains(lower, ".env") {
-					_, _ = fmt.Fprintln(os.Stderr, "  redactyl fix dotenv --from", f.Path, "--add-ignore --summary remediation.json")
-					continue
-				}
-				_, _ = fmt.Fprintln(os.Stderr, "  redactyl fix redact --file", f.Path, "--pattern", "'"+regexpQuote(f.Match)+"'", "--replace '<redacted>' --summary remediation.json")
-			}
+			printGuide(os.Stderr, newFindings)
 		}
 		if res.ArtifactStats.AbortedByBytes+res.ArtifactStats.AbortedByEntries+res.ArtifactStats.AbortedByDepth+res.ArtifactStats.AbortedByTime > 0 {
 			_, _ = fmt.Fprintf(os.Stderr, "\nArtifact limits: bytes=%d entries=%d depth=%d time=%d\n", res.ArtifactStats.AbortedByBytes, res.ArtifactStats.AbortedByEntries, res.ArtifactStats.AbortedByDepth, res.ArtifactStats.AbortedByTime)
@@ -406,15 +583,7 @@ func runScan(cmd *cobra.Command, _ []string) error {
 	case flagTable:
 		report.PrintTable(os.Stdout, newFindings, report.PrintOptions{NoColor: flagNoColor, Duration: res.Duration, FilesScanned: res.FilesScanned, TotalFiles: total, TotalFindings: len(res.Findings)})
 		if flagGuide && len(newFindings) > 0 {
-			_, _ = fmt.Fprintln(os.Stderr, "\nSuggested remediation commands:")
-			for _, f := range newFindings {
-				lower := strings.ToLower(f.Path)
-				if strings.HasSuffix(lower, ".env") || strings.Contains(lower, ".env") {
-					_, _ = fmt.Fprintln(os.Stderr, "  redactyl fix dotenv --from", f.Path, "--add-ignore --summary remediation.json")
-					continue
-				}
-				_, _ = fmt.Fprintln(os.Stderr, "  redactyl fix redact --file", f.Path, "--pattern", "'"+regexpQuote(f.Match)+"'", "--replace '<redacted>' --summary remediation.json")
-			}
+			printGuide(os.Stderr, newFindings)
 		}
 		if res.ArtifactStats.AbortedByBytes+res.ArtifactStats.AbortedByEntries+res.ArtifactStats.AbortedByDepth+res.ArtifactStats.AbortedByTime > 0 {
 			_, _ = fmt.Fprintf(os.Stderr, "\nArtifact limits: bytes=%d entries=%d depth=%d time=%d\n", res.ArtifactStats.AbortedByBytes, res.ArtifactStats.AbortedByEntries, res.ArtifactStats.AbortedByDepth, res.ArtifactStats.AbortedByTime)
@@ -422,23 +591,20 @@ func runScan(cmd *cobra.Command, _ []string) error {
 	default:
 		report.PrintTable(os.Stdout, newFindings, report.PrintOptions{NoColor: flagNoColor, Duration: res.Duration, FilesScanned: res.FilesScanned, TotalFiles: total, TotalFindings: len(res.Findings)})
 		if flagGuide && len(newFindings) > 0 {
-			_, _ = fmt.Fprintln(os.Stderr, "\nSuggested remediation commands:")
-			for _, f := range newFindings {
-				lower := strings.ToLower(f.Path)
-				if strings.HasSuffix(lower, ".env") || strings.Contains(lower, ".env") {
-					_, _ = fmt.Fprintln(os.Stderr, "  redactyl fix dotenv --from", f.Path, "--add-ignore --summary remediation.json")
-					continue
-				}
-				_, _ = fmt.Fprintln(os.Stderr, "  redactyl fix redact --file", f.Path, "--pattern", "'"+regexpQuote(f.Match)+"'", "--replace '<redacted>' --summary remediation.json")
-			}
+			printGuide(os.Stderr, newFindings)
 		}
 		if res.ArtifactStats.AbortedByBytes+res.ArtifactStats.AbortedByEntries+res.ArtifactStats.AbortedByDepth+res.ArtifactStats.AbortedByTime > 0 {
 			_, _ = fmt.Fprintf(os.Stderr, "\nArtifact limits: bytes=%d entries=%d depth=%d time=%d\n", res.ArtifactStats.AbortedByBytes, res.ArtifactStats.AbortedByEntries, res.ArtifactStats.AbortedByDepth, res.ArtifactStats.AbortedByTime)
 		}
 	}
 
+	if flagCoverage {
+		_, _ = fmt.Fprintln(os.Stderr)
+		report.PrintCoverage(os.Stderr, res.Coverage)
+	}
+
 	if flagUploadURL != "" {
-		if err := uploadFindings(abs, flagUploadURL, flagUploadToken, flagNoUploadMeta, convertFindings(newFindings)); err != nil {
+		if err := uploadFindings(root, flagUploadURL, flagUploadToken, flagNoUploadMeta, convertFindings(newFindings)); err != nil {
 			_, _ = fmt.Fprintln(os.Stderr, "upload warning:", err)
 		}
 	}
@@ -448,11 +614,265 @@ func runScan(cmd *cobra.Command, _ []string) error {
 	}
 
 	if report.ShouldFail(newFindings, flagFailOn) {
+		cleanup()
 		os.Exit(1)
 	}
 	return nil
 }
 
+const (
+	formatGitLab      = "gitlab-secret-detection"
+	formatCodeClimate = "codeclimate"
+	formatTemplate    = "template"
+	formatMarkdown    = "markdown"
+)
+
+// scanFormats lists the values --format accepts.
+var scanFormats = []string{"table", "text", "json", "ndjson", "sarif", formatGitLab, formatCodeClimate, formatMarkdown, formatTemplate}
+
+// applyFormat maps --format onto the individual output flags it stands for.
+func applyFormat() error {
+	if flagTemplate != "" && flagFormat == "" {
+		flagFormat = formatTemplate
+	}
+	switch flagFormat {
+	case "":
+	case "table":
+		flagTable = true
+	case "text":
+		flagText = true
+	case "json":
+		flagJSON = true
+	case "ndjson":
+		flagNDJSON = true
+	case "sarif":
+		flagSARIF = true
+	case formatTemplate:
+		if flagTemplate == "" {
+			return fmt.Errorf("--format template requires --template (a file or one of: %s)", strings.Join(report.BuiltinTemplateNames(), ", "))
+		}
+	case formatGitLab, formatCodeClimate, formatMarkdown:
+	default:
+		return fmt.Errorf("unknown --format %q (supported: %s)", flagFormat, strings.Join(scanFormats, ", "))
+	}
+	// NDJSON streams to stdout during the scan, so no other report can
+	// share it.
+	if flagNDJSON && ((flagFormat != "" && flagFormat != "ndjson") || flagJSON || flagSARIF) {
+		return fmt.Errorf("--ndjson cannot be combined with --json, --sarif or another --format")
+	}
+	return nil
+}
+
+// structuredOutput reports whether stdout carries a machine-readable report,
+// in which case progress and hints are not printed and the TUI stays off.
+func structuredOutput() bool {
+	return flagJSON || flagNDJSON || flagSARIF || flagFormat == formatGitLab || flagFormat == formatCodeClimate || flagFormat == formatTemplate || flagFormat == formatMarkdown
+}
+
+// writeMarkdownSummary writes the Markdown PR summary to stdout and, in
+// GitHub Actions, appends it to the job summary ($GITHUB_STEP_SUMMARY).
+func writeMarkdownSummary(root, target string, all, findings []types.Finding, baseline report.Baseline) error {
+	var baselined []types.Finding
+	for _, f := range all {
+		if report.IsBaselined(f, baseline.Items) {
+			baselined = append(baselined, f)
+		}
+	}
+	repo, commit, _ := git.RepoMetadata(root)
+	opts := report.MarkdownOptions{Target: target, BlobURL: blobURL(repo), Commit: commit}
+	var buf bytes.Buffer
+	if err := report.WriteMarkdownSummary(&buf, findings, baselined, opts); err != nil {
+		return err
+	}
+	if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("job summary: %w", err)
+	}
+	if _, err := f.Write(append(buf.Bytes(), '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("job summary: %w", err)
+	}
+	return f.Close()
+}
+
+// blobURL returns the web URL prefix for files in repo, as returned by
+// git.RepoMetadata. Only GitHub-style owner/name repositories are linked;
+// $GITHUB_SERVER_URL selects a GitHub Enterprise host.
+func blobURL(repo string) string {
+	if repo == "" || strings.Count(repo, "/") != 1 || strings.ContainsAny(repo, ":@ ") {
+		if repo = os.Getenv("GITHUB_REPOSITORY"); repo == "" {
+			return ""
+		}
+	}
+	server := os.Getenv("GITHUB_SERVER_URL")
+	if server == "" {
+		server = "https://github.com"
+	}
+	return strings.TrimSuffix(server, "/") + "/" + repo + "/blob"
+}
+
+// loadTemplate reads and checks a --template value: a template file or,
+// when no such file exists, the name of a built-in template.
+func loadTemplate(value string) (name, text string, err error) {
+	b, err := os.ReadFile(value)
+	if err == nil {
+		name, text = filepath.Base(value), string(b)
+		return name, text, report.ValidateTemplate(name, text)
+	}
+	if builtin, ok := report.BuiltinTemplate(value); ok {
+		return value, builtin, nil
+	}
+	return "", "", fmt.Errorf("template %q: %w (built-in templates: %s)", value, err, strings.Join(report.BuiltinTemplateNames(), ", "))
+}
+
+// writeTemplateReport renders the findings that survived the baseline
+// through a template loaded by loadTemplate.
+func writeTemplateReport(w io.Writer, name, text, root, target string, res engine.Result, findings []types.Finding) error {
+	repo, commit, branch := git.RepoMetadata(root)
+	res.Findings = findings
+	opts := report.TemplateOptions{
+		Target:  target,
+		Version: version,
+		Repo:    report.TemplateRepo{Name: repo, Commit: commit, Branch: branch},
+		NoColor: flagNoColor || !isTerminal(os.Stdout),
+	}
+	return report.WriteTemplate(w, name, text, res, opts)
+}
+
+// maxMissingLFSLines caps how many missing LFS objects are listed.
+const maxMissingLFSLines = 10
+
+// writeHTMLReport renders the findings that survived the baseline as a
+// single-file HTML report at path.
+func writeHTMLReport(path string, res engine.Result, findings []types.Finding, target string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("html report: %w", err)
+	}
+	res.Findings = findings
+	opts := report.HTMLOptions{Target: target, Version: version, ShowSecrets: flagHTMLUnmasked}
+	if err := report.WriteHTML(f, res, opts); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("html report: %w", err)
+	}
+	return f.Close()
+}
+
+// writeJUnitReport writes the findings that survived the baseline as JUnit
+// XML at path, failing testcases at or above --fail-on.
+func writeJUnitReport(path string, res engine.Result, findings []types.Finding) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("junit report: %w", err)
+	}
+	res.Findings = findings
+	if err := report.WriteJUnit(f, res, report.JUnitOptions{FailOn: flagFailOn}); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("junit report: %w", err)
+	}
+	return f.Close()
+}
+
+func writeCodeClimateReport(path string, findings []types.Finding) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("codeclimate report: %w", err)
+	}
+	if err := report.WriteCodeClimate(f, findings); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("codeclimate report: %w", err)
+	}
+	return f.Close()
+}
+
+// printMissingLFS reports LFS pointers whose objects are not in the local
+// store; their real content was not scanned.
+func printMissingLFS(w io.Writer, missing []engine.MissingLFSObject) {
+	if len(missing) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "Git LFS: %d pointer(s) have no local object, so their content was not scanned (run 'git lfs fetch --all'):\n", len(missing))
+	for i, m := range missing {
+		if i == maxMissingLFSLines {
+			_, _ = fmt.Fprintf(w, "  ... and %d more\n", len(missing)-i)
+			break
+		}
+		where := m.Path
+		if m.Commit != "" {
+			where += " @ " + short(m.Commit)
+		}
+		_, _ = fmt.Fprintf(w, "  %s (oid %s)\n", where, short(m.OID))
+	}
+}
+
+// cloneRepo mirrors source into a new temporary directory and returns the
+// clone's path and a function that removes it.
+func cloneRepo(source string, opts git.CloneOptions) (string, func(), error) {
+	tmp, err := os.MkdirTemp("", "redactyl-repo-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(tmp) }
+	dir := filepath.Join(tmp, "repo.git")
+	if err := git.MirrorClone(source, dir, opts); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return dir, cleanup, nil
+}
+
+// printGuide prints suggested remediation commands for findings. Secrets that
+// only survive in stashes, reflogs or unreachable objects cannot be fixed in
+// the working tree; for those it prints the git commands that remove them.
+func printGuide(w io.Writer, findings []types.Finding) {
+	_, _ = fmt.Fprintln(w, "\nSuggested remediation commands:")
+	var stashes []string
+	seen := map[string]bool{}
+	prune := false
+	for _, f := range findings {
+		switch f.Metadata["history_status"] {
+		case engine.HistoryStash:
+			if name := f.Metadata["stash"]; name != "" && !seen[name] {
+				seen[name] = true
+				stashes = append(stashes, name)
+			}
+			prune = true
+			continue
+		case engine.HistoryReflog, engine.HistoryUnreachable:
+			prune = true
+			continue
+		}
+		lower := strings.ToLower(f.Path)
+		if strings.HasSuffix(lower, ".env") || strings.Contains(lower, ".env") {
+			_, _ = fmt.Fprintln(w, "  redactyl fix dotenv --from", f.Path, "--add-ignore --summary remediation.json")
+			continue
+		}
+		_, _ = fmt.Fprintln(w, "  redactyl fix redact --file", f.Path, "--pattern", "'"+regexpQuote(f.Match)+"'", "--replace '<redacted>' --summary remediation.json")
+	}
+	if !prune {
+		return
+	}
+	// Dropping an entry renumbers the ones after it, so drop from the end.
+	sort.Slice(stashes, func(i, j int) bool { return stashIndex(stashes[i]) > stashIndex(stashes[j]) })
+	for _, name := range stashes {
+		_, _ = fmt.Fprintln(w, "  git stash drop", name)
+	}
+	_, _ = fmt.Fprintln(w, "  git reflog expire --expire-unreachable=now --all && git gc --prune=now")
+}
+
+// stashIndex returns n for "stash@{n}".
+func stashIndex(name string) int {
+	n, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "stash@{"), "}"))
+	return n
+}
+
 func regexpQuote(s string) string {
 	replacer := strings.NewReplacer(`\`, `\\`, `.`, `\.`, `*`, `\*`, `+`, `\+`, `?`, `\?`, `(`, `\(`, `)`, `\)`, `[`, `\[`, `]`, `\]`, `{`, `\{`, `}`, `\}`, `^`, `\^`, `$`, `\$`, `|`, `\|`)
 	return replacer.Replace(s)
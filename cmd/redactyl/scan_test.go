@@ -1,10 +1,14 @@
 package redactyl
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/varalys/redactyl/internal/config"
+	"github.com/varalys/redactyl/internal/engine"
+	"github.com/varalys/redactyl/internal/types"
 )
 
 func strptr(s string) *string { return &s }
@@ -30,3 +34,65 @@ func TestResolveBudgets_Precedence(t *testing.T) {
 		t.Fatalf("global fallback failed: got (%v,%v)", b, g)
 	}
 }
+
+func TestPrintGuide_Forensics(t *testing.T) {
+	var buf bytes.Buffer
+	printGuide(&buf, []types.Finding{
+		{Path: "app.txt", Match: "x", Metadata: map[string]string{"history_status": engine.HistoryStash, "stash": "stash@{2}"}},
+		{Path: "b.txt", Match: "x", Metadata: map[string]string{"history_status": engine.HistoryStash, "stash": "stash@{10}"}},
+		{Path: "c.txt", Match: "x", Metadata: map[string]string{"history_status": engine.HistoryReflog}},
+		{Path: "main.go", Match: "tok"},
+	})
+	out := buf.String()
+	for _, want := range []string{"fix redact --file main.go", "git stash drop stash@{10}\n  git stash drop stash@{2}", "reflog expire --expire-unreachable=now --all && git gc --prune=now"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in guide:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "--file app.txt") || strings.Contains(out, "--file c.txt") {
+		t.Fatalf("forensic findings should not get working-tree fixes:\n%s", out)
+	}
+}
+
+func TestApplyFormat_NDJSONConflicts(t *testing.T) {
+	reset := func() {
+		flagFormat, flagTemplate = "", ""
+		flagNDJSON, flagJSON, flagSARIF, flagTable, flagText = false, false, false, false, false
+	}
+	t.Cleanup(reset)
+	for _, tc := range []struct {
+		name    string
+		set     func()
+		wantErr bool
+	}{
+		{"ndjson", func() { flagNDJSON = true }, false},
+		{"format ndjson", func() { flagFormat = "ndjson" }, false},
+		{"ndjson and format ndjson", func() { flagNDJSON, flagFormat = true, "ndjson" }, false},
+		{"ndjson and markdown", func() { flagNDJSON, flagFormat = true, formatMarkdown }, true},
+		{"ndjson and template", func() { flagNDJSON, flagTemplate = true, "csv" }, true},
+		{"ndjson and json", func() { flagNDJSON, flagJSON = true, true }, true},
+		{"format ndjson and sarif", func() { flagFormat, flagSARIF = "ndjson", true }, true},
+	} {
+		reset()
+		tc.set()
+		if err := applyFormat(); (err != nil) != tc.wantErr {
+			t.Fatalf("%s: unexpected error %v", tc.name, err)
+		}
+	}
+}
+
+func TestBlobURL(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("GITHUB_SERVER_URL", "")
+	if got := blobURL("acme/widgets"); got != "https://github.com/acme/widgets/blob" {
+		t.Fatalf("unexpected URL %q", got)
+	}
+	if got := blobURL("//gitlab.example.com/group/sub/proj"); got != "" {
+		t.Fatalf("non-GitHub remotes should not be linked, got %q", got)
+	}
+	t.Setenv("GITHUB_REPOSITORY", "acme/mirror")
+	t.Setenv("GITHUB_SERVER_URL", "https://ghe.example.com/")
+	if got := blobURL(""); got != "https://ghe.example.com/acme/mirror/blob" {
+		t.Fatalf("expected Actions environment to be used, got %q", got)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"sync"
 
 	"github.com/varalys/redactyl/internal/ignore"
+	"github.com/varalys/redactyl/internal/types"
 	yaml "gopkg.in/yaml.v3"
 )
 
@@ -73,12 +74,25 @@ type Stats struct {
 	AbortedByEntries int
 	AbortedByDepth   int
 	AbortedByTime    int
+	Aborts           []Abort // each abort, in the order they happened
 }
 
-func (s *Stats) add(reason string) {
+// statsMu guards Stats updates: archives are scanned by several workers.
+var statsMu sync.Mutex
+
+// Abort is one artifact whose scan stopped at a guardrail.
+type Abort struct {
+	Path   string // artifact or nested archive, e.g. bundle.zip::inner.tar
+	Reason string // bytes, entries, depth or time
+}
+
+func (s *Stats) add(path, reason string) {
 	if s == nil || reason == "" {
 		return
 	}
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	s.Aborts = append(s.Aborts, Abort{Path: path, Reason: reason})
 	switch reason {
 	case "bytes":
 		s.AbortedByBytes++
@@ -91,6 +105,24 @@ func (s *Stats) add(reason string) {
 	}
 }
 
+// Extract is a fragment of a file handed to the detectors together with the
+// position of its first byte, so findings can report the real line and
+// column in the original file rather than in the fragment.
+type Extract struct {
+	Path     string
+	Data     []byte
+	Line     int // 1-based line of Data[0] in the file
+	Column   int // 1-based column of Data[0] in the file
+	Metadata map[string]string
+}
+
+// ExtractFunc receives positioned fragments from selective extraction.
+type ExtractFunc func(x Extract)
+
+// FindingFunc receives structural findings raised directly by artifact
+// analysers, as opposed to content emitted for the detectors.
+type FindingFunc func(f types.Finding)
+
 // PathAllowFunc returns true if the given relative artifact filename should be
 // considered for deep scanning (after .redactylignore filtering). When nil,
 // all artifact filenames are allowed.
@@ -268,7 +300,7 @@ func ScanContainersWithStats(root string, limits Limits, allow PathAllowFunc, em
 		for {
 			if r := limitsExceededReason(limits, decompressed, entries, 0, deadline); r != "" {
 				if stats != nil {
-					stats.add(r)
+					stats.add(rel, r)
 				}
 				return nil
 			}
@@ -307,9 +339,27 @@ func ScanIaC(root string, limits Limits, emit func(path string, data []byte)) er
 // ScanIaCWithFilter scans IaC hotspots like Terraform state files and kubeconfigs.
 // For small Terraform state files, it extracts likely secret fields and emits them
 // individually to reduce noise. For larger files, it falls back to text emission
-// bounded by limits. Kubeconfigs are emitted as text for structured detectors to parse.
+// bounded by limits. Kubeconfig credentials are extracted the same way.
+// Extracted fragments are emitted under the real file path; use
+// ScanIaCWithFindings to also receive their position in the file.
 func ScanIaCWithFilter(root string, limits Limits, allow PathAllowFunc, emit func(path string, data []byte)) error {
+	return ScanIaCWithFindings(root, limits, allow, emit, nil, nil)
+}
+
+// ScanIaCWithFindings is like ScanIaCWithFilter but passes selectively
+// extracted fragments to extract with their source position, and also
+// analyses Terraform sources (.tf, .tfvars) and plan JSON, CloudFormation
+// templates, ARM templates and parameter files, Bicep sources, Pulumi
+// stack configs and exports, Dockerfiles and compose files, passing
+// structural findings such as literal credentials to report. Dockerfile and
+// compose values are also passed to extract. When extract is nil fragments
+// go to emit; when report is nil these sources are skipped.
+func ScanIaCWithFindings(root string, limits Limits, allow PathAllowFunc, emit func(path string, data []byte), extract ExtractFunc, report FindingFunc) error {
+	if extract == nil {
+		extract = func(x Extract) { emit(x.Path, x.Data) }
+	}
 	ign, _ := ignore.Load(filepath.Join(root, ".redactylignore"))
+	var modules *TerraformModules // loaded with the first HCL source
 	_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil
@@ -327,7 +377,10 @@ func ScanIaCWithFilter(root string, limits Limits, allow PathAllowFunc, emit fun
 		lower := strings.ToLower(rel)
 		isTF := strings.HasSuffix(lower, ".tfstate")
 		isKC := strings.HasSuffix(lower, ".kubeconfig") || isKubeConfigPath(rel)
-		if !isTF && !isKC {
+		isHCL := report != nil && IsTerraformPath(rel)
+		isDocker := report != nil && (IsDockerfilePath(rel) || IsComposePath(rel))
+		isTemplate := report != nil && !isTF && !isKC && !isDocker && isCloudIaCCandidate(rel)
+		if !isTF && !isKC && !isHCL && !isDocker && !isTemplate {
 			return nil
 		}
 		// establish time budget
@@ -337,6 +390,53 @@ func ScanIaCWithFilter(root string, limits Limits, allow PathAllowFunc, emit fun
 			deadline = started.Add(limits.TimeBudget)
 		}
 		var decompressed int64
+		// Dockerfiles and compose files: each value goes to the detectors
+		// with its field, plus structural findings.
+		if isDocker {
+			f, err := os.Open(p)
+			if err != nil {
+				return nil
+			}
+			defer safeClose(f)
+			b, readErr := readAllBounded(f, limits, &decompressed, deadline)
+			if readErr != nil {
+				return nil
+			}
+			extracts, findings := InspectDocker(filepath.ToSlash(rel), b)
+			for _, x := range extracts {
+				x.Path = rel
+				extract(x)
+			}
+			for _, finding := range findings {
+				report(finding)
+			}
+			return nil
+		}
+		// Terraform sources, plan JSON and cloud templates: structural
+		// analysis only, the regular filesystem walk already hands their
+		// content to detectors.
+		if isHCL || isTemplate {
+			f, err := os.Open(p)
+			if err != nil {
+				return nil
+			}
+			defer safeClose(f)
+			b, readErr := readAllBounded(f, limits, &decompressed, deadline)
+			if readErr != nil {
+				return nil
+			}
+			if isHCL && modules == nil {
+				modules = LoadTerraformModules(root)
+			}
+			findings := InspectTerraform(filepath.ToSlash(rel), b, modules)
+			if !isHCL && len(findings) == 0 {
+				findings = InspectCloudIaC(filepath.ToSlash(rel), b)
+			}
+			for _, finding := range findings {
+				report(finding)
+			}
+			return nil
+		}
 		// Terraform state selective scan
 		if isTF {
 			f, err := os.Open(p)
@@ -352,13 +452,33 @@ func ScanIaCWithFilter(root string, limits Limits, allow PathAllowFunc, emit fun
 					var data any
 					if json.Unmarshal(b, &data) == nil {
 						count := 0
+						positions := jsonValueOffsets(b)
+						lines := lineOffsets(b)
 						emitKV := func(pathStr string, value string) {
 							// stop if limits exceeded by entries
 							if limitsExceeded(limits, decompressed, count, 0, deadline) {
 								return
 							}
-							buf := []byte(pathStr + ": " + value)
-							emit(rel+"::json:"+pathStr, buf)
+							leaf, ok := positions[pathStr]
+							if !ok {
+								return
+							}
+							// {"api_key": {"value": "..."}} is reported from the outer key
+							member := leaf
+							if parent := strings.TrimSuffix(pathStr, ".value"); parent != pathStr {
+								if off, ok := positions[parent]; ok {
+									member = off
+								}
+							}
+							start, end := jsonEntrySpan(b, member, leaf)
+							line, col := offsetToLineCol(lines, start)
+							extract(Extract{
+								Path:     rel,
+								Data:     b[start:end],
+								Line:     line,
+								Column:   col,
+								Metadata: map[string]string{"iac_path": pathStr},
+							})
 							count++
 						}
 						extractSensitiveJSON("", data, emitKV)
@@ -384,7 +504,7 @@ func ScanIaCWithFilter(root string, limits Limits, allow PathAllowFunc, emit fun
 				return nil
 			}
 			if len(b) > 0 {
-				if emitted := tryExtractKubeconfig(rel, b, emit); !emitted {
+				if emitted := tryExtractKubeconfig(rel, b, extract); !emitted {
 					emit(rel, b)
 				}
 			}
@@ -799,71 +919,66 @@ func looksNonTextMIME(path string, b []byte) bool {
 	return false
 }
 
-func tryExtractKubeconfig(rel string, b []byte, emit func(path string, data []byte)) bool {
-	type userEntry struct {
-		Name string `yaml:"name"`
-		User struct {
-			Token          string         `yaml:"token"`
-			ClientCertData string         `yaml:"client-certificate-data"`
-			ClientKeyData  string         `yaml:"client-key-data"`
-			Exec           map[string]any `yaml:"exec"`
-			AuthProvider   map[string]any `yaml:"auth-provider"`
-		} `yaml:"user"`
-	}
-	type clusterEntry struct {
-		Name    string `yaml:"name"`
-		Cluster struct {
-			CACertData string `yaml:"certificate-authority-data"`
-		} `yaml:"cluster"`
-	}
-	var doc struct {
-		Users    []userEntry    `yaml:"users"`
-		Clusters []clusterEntry `yaml:"clusters"`
-	}
+// tryExtractKubeconfig extracts credential entries (user tokens, client
+// certificates and keys, auth-provider tokens and cluster CA data) from a
+// kubeconfig, keeping each entry's position in the file.
+func tryExtractKubeconfig(rel string, b []byte, extract ExtractFunc) bool {
 	if !looksLikeYAML(b) {
 		return false
 	}
-	if err := yaml.Unmarshal(b, &doc); err != nil {
+	var root yaml.Node
+	if err := yaml.Unmarshal(b, &root); err != nil || len(root.Content) == 0 {
 		return false
 	}
+	doc := root.Content[0]
+	lines := lineOffsets(b)
 	emitted := false
-	for i, u := range doc.Users {
-		base := rel + "::yaml:users[" + strconv.Itoa(i) + "].user"
-		if u.User.Token != "" {
-			emit(base+".token", []byte(u.User.Token))
-			emitted = true
-		}
-		if u.User.ClientCertData != "" {
-			emit(base+".client-certificate-data", []byte(u.User.ClientCertData))
+	emitEntry := func(m *yaml.Node, key, label string) {
+		for i := 0; m != nil && m.Kind == yaml.MappingNode && i+1 < len(m.Content); i += 2 {
+			k, v := m.Content[i], m.Content[i+1]
+			if k.Value != key || v.Kind != yaml.ScalarNode || v.Value == "" {
+				continue
+			}
+			_, end, ok := yamlValueSpan(b, lines, k, v)
+			if !ok || k.Line < 1 || k.Line > len(lines) {
+				continue
+			}
+			start := lines[k.Line-1] + k.Column - 1
+			extract(Extract{
+				Path:     rel,
+				Data:     bytes.TrimRight(b[start:end], " \t\r\n"),
+				Line:     k.Line,
+				Column:   k.Column,
+				Metadata: map[string]string{"iac_path": label + "." + key},
+			})
 			emitted = true
 		}
-		if u.User.ClientKeyData != "" {
-			emit(base+".client-key-data", []byte(u.User.ClientKeyData))
-			emitted = true
+	}
+	for i, u := range yamlSequence(mappingValue(doc, "users")) {
+		base := "users[" + strconv.Itoa(i) + "].user"
+		user := mappingValue(u, "user")
+		for _, key := range []string{"token", "client-certificate-data", "client-key-data"} {
+			emitEntry(user, key, base)
 		}
-		if ap := u.User.AuthProvider; ap != nil {
-			if cfg, ok := ap["config"].(map[string]any); ok {
-				if t, ok := cfg["access-token"].(string); ok && t != "" {
-					emit(base+".auth-provider.config.access-token", []byte(t))
-					emitted = true
-				}
-				if rt, ok := cfg["refresh-token"].(string); ok && rt != "" {
-					emit(base+".auth-provider.config.refresh-token", []byte(rt))
-					emitted = true
-				}
-			}
+		cfg := mappingValue(mappingValue(user, "auth-provider"), "config")
+		for _, key := range []string{"access-token", "refresh-token"} {
+			emitEntry(cfg, key, base+".auth-provider.config")
 		}
 	}
-	for i, c := range doc.Clusters {
-		base := rel + "::yaml:clusters[" + strconv.Itoa(i) + "].cluster"
-		if c.Cluster.CACertData != "" {
-			emit(base+".certificate-authority-data", []byte(c.Cluster.CACertData))
-			emitted = true
-		}
+	for i, c := range yamlSequence(mappingValue(doc, "clusters")) {
+		base := "clusters[" + strconv.Itoa(i) + "].cluster"
+		emitEntry(mappingValue(c, "cluster"), "certificate-authority-data", base)
 	}
 	return emitted
 }
 
+func yamlSequence(n *yaml.Node) []*yaml.Node {
+	if n == nil || n.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return n.Content
+}
+
 var yamlDocRx = regexp.MustCompile(`(?m)^\s*(apiVersion|clusters|users)\s*:`)
 
 func looksLikeYAML(b []byte) bool {
@@ -970,7 +1085,7 @@ func scanArchiveFileWithStats(fullPath string, rel string, limits Limits, decomp
 		if readErr != nil {
 			if stats != nil {
 				if r := limitsExceededReason(limits, *decompressed, *entries, depth, deadline); r != "" {
-					stats.add(r)
+					stats.add(rel, r)
 				}
 			}
 			return nil
@@ -1000,7 +1115,7 @@ func scanZipReaderWithStats(archivePath string, limits Limits, decompressed *int
 		for _, f := range zr.File {
 			if r := limitsExceededReason(limits, *decompressed, *entries, depth, deadline); r != "" {
 				if stats != nil {
-					stats.add(r)
+					stats.add(archivePath, r)
 				}
 				return nil
 			}
@@ -1016,7 +1131,7 @@ func scanZipReaderWithStats(archivePath string, limits Limits, decompressed *int
 			if readErr != nil {
 				if stats != nil {
 					if r := limitsExceededReason(limits, *decompressed, *entries, depth, deadline); r != "" {
-						stats.add(r)
+						stats.add(archivePath, r)
 					}
 				}
 				continue
@@ -1029,7 +1144,7 @@ func scanZipReaderWithStats(archivePath string, limits Limits, decompressed *int
 				if depth < limits.MaxDepth && isArchivePath(name) {
 					_ = scanNestedArchiveWithStats(archivePath+"::"+name, name, b, limits, decompressed, entries, depth+1, deadline, emit, stats) //nolint:errcheck
 				} else if stats != nil && isArchivePath(name) {
-					stats.add("depth")
+					stats.add(archivePath+"::"+name, "depth")
 				}
 				continue
 			}
@@ -1047,7 +1162,7 @@ func scanTarReaderWithStats(archivePath string, limits Limits, decompressed *int
 	for {
 		if r := limitsExceededReason(limits, *decompressed, *entries, depth, deadline); r != "" {
 			if stats != nil {
-				stats.add(r)
+				stats.add(archivePath, r)
 			}
 			return nil
 		}
@@ -1065,7 +1180,7 @@ func scanTarReaderWithStats(archivePath string, limits Limits, decompressed *int
 		if readErr != nil {
 			if stats != nil {
 				if r := limitsExceededReason(limits, *decompressed, *entries, depth, deadline); r != "" {
-					stats.add(r)
+					stats.add(archivePath, r)
 				}
 			}
 			continue
@@ -1078,7 +1193,7 @@ func scanTarReaderWithStats(archivePath string, limits Limits, decompressed *int
 			if depth < limits.MaxDepth && isArchivePath(name) {
 				_ = scanNestedArchiveWithStats(archivePath+"::"+name, name, b, limits, decompressed, entries, depth+1, deadline, emit, stats) //nolint:errcheck
 			} else if stats != nil && isArchivePath(name) {
-				stats.add("depth")
+				stats.add(archivePath+"::"+name, "depth")
 			}
 			continue
 		}
@@ -1098,7 +1213,7 @@ func scanNestedArchiveWithStats(pathChain string, name string, blob []byte, limi
 		for _, f := range zr.File {
 			if r := limitsExceededReason(limits, *decompressed, *entries, depth, deadline); r != "" {
 				if stats != nil {
-					stats.add(r)
+					stats.add(pathChain, r)
 				}
 				return nil
 			}
@@ -1114,7 +1229,7 @@ func scanNestedArchiveWithStats(pathChain string, name string, blob []byte, limi
 			if readErr != nil {
 				if stats != nil {
 					if r := limitsExceededReason(limits, *decompressed, *entries, depth, deadline); r != "" {
-						stats.add(r)
+						stats.add(pathChain, r)
 					}
 				}
 				continue
@@ -1127,7 +1242,7 @@ func scanNestedArchiveWithStats(pathChain string, name string, blob []byte, limi
 				if depth < limits.MaxDepth && isArchivePath(fname) {
 					_ = scanNestedArchiveWithStats(pathChain+"::"+fname, fname, b, limits, decompressed, entries, depth+1, deadline, emit, stats) //nolint:errcheck
 				} else if stats != nil && isArchivePath(fname) {
-					stats.add("depth")
+					stats.add(pathChain+"::"+fname, "depth")
 				}
 				continue
 			}
@@ -1157,7 +1272,7 @@ func scanNestedArchiveWithStats(pathChain string, name string, blob []byte, limi
 		if readErr != nil {
 			if stats != nil {
 				if r := limitsExceededReason(limits, *decompressed, *entries, depth, deadline); r != "" {
-					stats.add(r)
+					stats.add(pathChain, r)
 				}
 			}
 			return nil
@@ -309,6 +309,10 @@ func TestScanArchivesWithStats_DepthCounter(t *testing.T) {
 	if stats.AbortedByDepth == 0 {
 		t.Fatalf("expected depth abort to be recorded; got %+v", *stats)
 	}
+	want := Abort{Path: "outer.zip::nested/inner.zip", Reason: "depth"}
+	if len(stats.Aborts) != stats.AbortedByDepth || stats.Aborts[0] != want {
+		t.Fatalf("expected abort %+v to be listed; got %+v", want, stats.Aborts)
+	}
 }
 
 func TestScanArchivesWithStats_TimeCounter(t *testing.T) {
@@ -329,30 +333,54 @@ func TestScanArchivesWithStats_TimeCounter(t *testing.T) {
 func TestScanIaC_TerraformStateSelective(t *testing.T) {
 	dir := t.TempDir()
 	// Small tfstate with sensitive-looking keys
-	state := `{"version":4,"resources":[{"type":"kubernetes_secret","instances":[{"attributes":{"data":{"password":"p@ss","token":"tkn"}}}]}],"outputs":{"api_key":{"value":"abc123"}}}`
+	state := `{
+  "version": 4,
+  "resources": [
+    {
+      "type": "kubernetes_secret",
+      "instances": [{"attributes": {"data": {"password": "p@ss", "token": "tkn"}}}]
+    }
+  ],
+  "outputs": {
+    "api_key": {
+      "value": "abc123",
+      "sensitive": true
+    }
+  }
+}`
 	path := filepath.Join(dir, "terraform.tfstate")
 	if err := os.WriteFile(path, []byte(state), 0644); err != nil {
 		t.Fatal(err)
 	}
 	lim := Limits{MaxArchiveBytes: 1 << 20, MaxEntries: 100, MaxDepth: 1, TimeBudget: 1 * time.Second}
-	var got []string
-	emit := func(p string, b []byte) { got = append(got, p+"::"+string(b)) }
-	if err := ScanIaCWithFilter(dir, lim, nil, emit); err != nil {
-		t.Fatalf("ScanIaCWithFilter error: %v", err)
+	got := map[string]Extract{}
+	extract := func(x Extract) { got[x.Metadata["iac_path"]] = x }
+	if err := ScanIaCWithFindings(dir, lim, nil, func(string, []byte) {}, extract, nil); err != nil {
+		t.Fatalf("ScanIaCWithFindings error: %v", err)
 	}
-	// Expect emitted kv entries labeled with json paths
-	foundToken := false
-	foundAPIKey := false
-	for _, s := range got {
-		if strings.Contains(s, "json:") && strings.Contains(s, "token") {
-			foundToken = true
-		}
-		if strings.Contains(s, "json:") && strings.Contains(s, "outputs.api_key") {
-			foundAPIKey = true
-		}
+	token, ok := got["resources[0].instances[0].attributes.data.token"]
+	if !ok {
+		t.Fatalf("expected token extraction, got: %v", got)
+	}
+	if token.Path != "terraform.tfstate" || string(token.Data) != `"token": "tkn"` || token.Line != 6 || token.Column != 66 {
+		t.Fatalf("unexpected token extract: %+v (%q)", token, token.Data)
 	}
-	if !foundToken || !foundAPIKey {
-		t.Fatalf("expected selective json emissions, got: %v", got)
+	apiKey, ok := got["outputs.api_key.value"]
+	if !ok {
+		t.Fatalf("expected outputs.api_key extraction, got: %v", got)
+	}
+	// Nested {"value": ...} form is reported from the outer key.
+	if apiKey.Line != 10 || apiKey.Column != 5 || !strings.HasSuffix(string(apiKey.Data), `"abc123"`) {
+		t.Fatalf("unexpected api_key extract: %+v (%q)", apiKey, apiKey.Data)
+	}
+
+	// Without an extract callback fragments are emitted under the real path.
+	var emitted []string
+	if err := ScanIaCWithFilter(dir, lim, nil, func(p string, b []byte) { emitted = append(emitted, p+"::"+string(b)) }); err != nil {
+		t.Fatalf("ScanIaCWithFilter error: %v", err)
+	}
+	if len(emitted) != 3 || !strings.HasPrefix(emitted[0], "terraform.tfstate::") {
+		t.Fatalf("expected 3 fragments for terraform.tfstate, got: %v", emitted)
 	}
 }
 
@@ -368,16 +396,20 @@ func TestScanIaC_Kubeconfig(t *testing.T) {
 		t.Fatal(err)
 	}
 	lim := Limits{MaxArchiveBytes: 1 << 20, MaxEntries: 100, MaxDepth: 1, TimeBudget: 1 * time.Second}
-	var got []string
-	emit := func(p string, b []byte) { got = append(got, p+"::"+string(b)) }
-	if err := ScanIaCWithFilter(dir, lim, nil, emit); err != nil {
-		t.Fatalf("ScanIaCWithFilter error: %v", err)
+	var got []Extract
+	extract := func(x Extract) { got = append(got, x) }
+	if err := ScanIaCWithFindings(dir, lim, nil, func(string, []byte) {}, extract, nil); err != nil {
+		t.Fatalf("ScanIaCWithFindings error: %v", err)
 	}
-	if len(got) == 0 {
-		t.Fatalf("expected emitted kubeconfig content")
+	if len(got) != 1 {
+		t.Fatalf("expected one kubeconfig extract, got: %v", got)
+	}
+	x := got[0]
+	if x.Path != filepath.Join(".kube", "config") || string(x.Data) != "token: abcdef" || x.Line != 6 || x.Column != 5 {
+		t.Fatalf("unexpected kubeconfig extract: %+v (%q)", x, x.Data)
 	}
-	if !strings.HasPrefix(got[0], ".kube/config::") {
-		t.Fatalf("expected path prefix .kube/config, got: %v", got[0])
+	if x.Metadata["iac_path"] != "users[0].user.token" {
+		t.Fatalf("unexpected iac_path: %v", x.Metadata)
 	}
 }
 
@@ -0,0 +1,411 @@
+package artifacts
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/varalys/redactyl/internal/types"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Detector IDs for structural findings raised on cloud IaC templates.
+const (
+	DetectorCloudFormationLiteral = "cloudformation-literal-credential"
+	DetectorARMLiteral            = "arm-literal-credential"
+	DetectorBicepLiteral          = "bicep-literal-credential"
+	DetectorPulumiPlaintext       = "pulumi-plaintext-secret"
+)
+
+// pulumiSecretSig marks a secret value in Pulumi state and stack exports.
+const pulumiSecretSig = "4dabf18193072939515e22adb298388d"
+
+// cloudTemplateMaxBytes bounds structured parsing of templates and exports.
+const cloudTemplateMaxBytes = 2 << 20 // 2 MiB
+
+// isCloudIaCCandidate reports whether path has an extension used by
+// CloudFormation, ARM/Bicep or Pulumi files.
+func isCloudIaCCandidate(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yaml", ".yml", ".template", ".bicep", ".bicepparam":
+		return true
+	}
+	return false
+}
+
+// InspectCloudIaC returns structural findings for literal credentials in
+// CloudFormation templates (parameter defaults, NoEcho misuse, resource
+// properties), ARM templates and parameter files, Bicep sources, and Pulumi
+// stack configs and exports with decrypted secrets. Findings report the
+// logical resource ID and property path in the "iac_address" metadata key.
+func InspectCloudIaC(path string, data []byte) []types.Finding {
+	if len(data) == 0 || len(data) > cloudTemplateMaxBytes {
+		return nil
+	}
+	lower := strings.ToLower(path)
+	switch ext := filepath.Ext(lower); {
+	case ext == ".bicep" || ext == ".bicepparam":
+		return inspectBicep(path, data)
+	case strings.HasPrefix(filepath.Base(lower), "pulumi.") && (ext == ".yaml" || ext == ".yml"):
+		return inspectPulumiConfig(path, data)
+	}
+	switch {
+	case bytes.Contains(data, []byte("AWSTemplateFormatVersion")) || bytes.Contains(data, []byte("AWS::")):
+		return inspectCloudFormation(path, data)
+	case bytes.Contains(data, []byte("schema.management.azure.com")):
+		return inspectARM(path, data)
+	case bytes.Contains(data, []byte(pulumiSecretSig)):
+		return inspectPulumiExport(path, data)
+	}
+	return nil
+}
+
+func parseYAMLDocument(data []byte) *yaml.Node {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil || len(root.Content) == 0 {
+		return nil
+	}
+	if root.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	return root.Content[0]
+}
+
+// walkYAMLScalars calls fn for every scalar below n with its dotted key path
+// (sequence elements as [i]) and the key of the enclosing mapping entry.
+func walkYAMLScalars(n *yaml.Node, prefix, key string, fn func(path, key string, val *yaml.Node)) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k := n.Content[i].Value
+			p := k
+			if prefix != "" {
+				p = prefix + "." + k
+			}
+			walkYAMLScalars(n.Content[i+1], p, k, fn)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			walkYAMLScalars(c, prefix+"["+strconv.Itoa(i)+"]", key, fn)
+		}
+	case yaml.ScalarNode:
+		fn(prefix, key, n)
+	}
+}
+
+// yamlLiteralString reports whether n is a plain string literal (no YAML
+// intrinsic function tag such as !Ref or !Sub) long enough to be a secret.
+func yamlLiteralString(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!str" && len(n.Value) >= 4
+}
+
+// yamlValueColumn returns the column of the first character of a scalar's
+// value, skipping the opening quote of quoted (and JSON) strings.
+func yamlValueColumn(n *yaml.Node) int {
+	if n.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		return n.Column + 1
+	}
+	return n.Column
+}
+
+// --- CloudFormation ---
+
+func inspectCloudFormation(path string, data []byte) []types.Finding {
+	doc := parseYAMLDocument(data)
+	if doc == nil {
+		return nil
+	}
+	resources := mappingValue(doc, "Resources")
+	if mappingValue(doc, "AWSTemplateFormatVersion") == nil && resources == nil {
+		return nil
+	}
+	var findings []types.Finding
+	if params := mappingValue(doc, "Parameters"); params != nil && params.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(params.Content); i += 2 {
+			name, p := params.Content[i].Value, params.Content[i+1]
+			def := mappingValue(p, "Default")
+			if def == nil || !yamlLiteralString(def) {
+				continue
+			}
+			noEcho := mappingValue(p, "NoEcho")
+			address := "Parameters." + name + ".Default"
+			switch {
+			case noEcho != nil && strings.EqualFold(noEcho.Value, "true"):
+				findings = append(findings, iacFinding(path, def.Line, yamlValueColumn(def), DetectorCloudFormationLiteral, "cloudformation", address, def.Value,
+					"NoEcho parameter has a Default value, which is stored in the template in plaintext", 0.9))
+			case iacCredentialKey(name):
+				findings = append(findings, iacFinding(path, def.Line, yamlValueColumn(def), DetectorCloudFormationLiteral, "cloudformation", address, def.Value,
+					"literal credential in parameter default", 0.8))
+			}
+		}
+	}
+	if resources != nil && resources.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(resources.Content); i += 2 {
+			logicalID := resources.Content[i].Value
+			props := mappingValue(resources.Content[i+1], "Properties")
+			if props == nil {
+				continue
+			}
+			walkYAMLScalars(props, "", "", func(p, key string, val *yaml.Node) {
+				if !yamlLiteralString(val) || !iacCredentialKey(key) || strings.HasPrefix(val.Value, "{{resolve:") {
+					return
+				}
+				findings = append(findings, iacFinding(path, val.Line, yamlValueColumn(val), DetectorCloudFormationLiteral, "cloudformation", logicalID+".Properties."+p, val.Value,
+					"literal credential in resource property", 0.8))
+			})
+		}
+	}
+	return findings
+}
+
+// --- ARM ---
+
+func inspectARM(path string, data []byte) []types.Finding {
+	doc := parseYAMLDocument(data)
+	if doc == nil {
+		return nil
+	}
+	schema := mappingValue(doc, "$schema")
+	if schema == nil {
+		return nil
+	}
+	var findings []types.Finding
+	params := mappingValue(doc, "parameters")
+	switch {
+	case strings.Contains(schema.Value, "deploymentParameters"):
+		// Parameter files: literal values for sensitive parameters. Key Vault
+		// references are objects and are skipped.
+		if params == nil || params.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(params.Content); i += 2 {
+			name := params.Content[i].Value
+			v := mappingValue(params.Content[i+1], "value")
+			if v == nil || !yamlLiteralString(v) || !iacCredentialKey(name) {
+				continue
+			}
+			findings = append(findings, iacFinding(path, v.Line, yamlValueColumn(v), DetectorARMLiteral, "arm", "parameters."+name+".value", v.Value,
+				"literal credential in ARM parameter file", 0.8))
+		}
+		return findings
+	case !strings.Contains(schema.Value, "deploymentTemplate"):
+		return nil
+	}
+	if params != nil && params.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(params.Content); i += 2 {
+			name, p := params.Content[i].Value, params.Content[i+1]
+			def := mappingValue(p, "defaultValue")
+			if def == nil || !yamlLiteralString(def) || strings.HasPrefix(def.Value, "[") {
+				continue
+			}
+			typ := ""
+			if t := mappingValue(p, "type"); t != nil {
+				typ = strings.ToLower(t.Value)
+			}
+			address := "parameters." + name + ".defaultValue"
+			switch {
+			case typ == "securestring" || typ == "secureobject":
+				findings = append(findings, iacFinding(path, def.Line, yamlValueColumn(def), DetectorARMLiteral, "arm", address, def.Value,
+					"secure parameter has a defaultValue, which is stored in the template in plaintext", 0.9))
+			case iacCredentialKey(name):
+				findings = append(findings, iacFinding(path, def.Line, yamlValueColumn(def), DetectorARMLiteral, "arm", address, def.Value,
+					"literal credential in parameter default", 0.8))
+			}
+		}
+	}
+	var walkResources func(list *yaml.Node, prefix string)
+	walkResources = func(list *yaml.Node, prefix string) {
+		for i, r := range yamlSequence(list) {
+			id := prefix + "resources[" + strconv.Itoa(i) + "]"
+			if n := mappingValue(r, "name"); n != nil && n.Value != "" && !strings.HasPrefix(n.Value, "[") {
+				id = n.Value
+			}
+			if props := mappingValue(r, "properties"); props != nil {
+				walkYAMLScalars(props, "", "", func(p, key string, val *yaml.Node) {
+					// ARM expressions ("[parameters('x')]") are references, not literals.
+					if !yamlLiteralString(val) || !iacCredentialKey(key) || strings.HasPrefix(val.Value, "[") {
+						return
+					}
+					findings = append(findings, iacFinding(path, val.Line, yamlValueColumn(val), DetectorARMLiteral, "arm", id+".properties."+p, val.Value,
+						"literal credential in resource property", 0.8))
+				})
+			}
+			walkResources(mappingValue(r, "resources"), id+".")
+		}
+	}
+	walkResources(mappingValue(doc, "resources"), "")
+	return findings
+}
+
+// --- Bicep ---
+
+var (
+	bicepParamRx    = regexp.MustCompile(`^\s*param\s+([A-Za-z_]\w*)(?:\s+[A-Za-z_]\w*)?\s*=\s*'((?:[^'\\]|\\.)*)'`)
+	bicepResourceRx = regexp.MustCompile(`^\s*resource\s+([A-Za-z_]\w*)\s+'`)
+	bicepPropertyRx = regexp.MustCompile(`^(\s*)([A-Za-z_]\w*)\s*:\s*(.*?)\s*$`)
+)
+
+// inspectBicep scans Bicep sources and .bicepparam files line by line for
+// literal parameter defaults and resource properties. Nesting is tracked by
+// indentation, which matches the formatting produced by the Bicep tooling.
+func inspectBicep(path string, data []byte) []types.Finding {
+	type frame struct {
+		indent int
+		key    string
+	}
+	var findings []types.Finding
+	var stack []frame
+	resource := ""
+	secure := false
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := sc.Text()
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "@secure()") {
+			secure = true
+			continue
+		}
+		if m := bicepParamRx.FindStringSubmatchIndex(text); m != nil {
+			name, value := text[m[2]:m[3]], text[m[4]:m[5]]
+			if len(value) >= 4 && !strings.Contains(value, "${") && (secure || iacCredentialKey(name)) {
+				desc, confidence := "literal credential in parameter default", 0.8
+				if secure {
+					desc, confidence = "@secure() parameter has a literal default, which is stored in plaintext", 0.9
+				}
+				findings = append(findings, iacFinding(path, line, m[4]+1, DetectorBicepLiteral, "bicep", "param."+name, value, desc, confidence))
+			}
+			secure = false
+			continue
+		}
+		secure = false
+		if m := bicepResourceRx.FindStringSubmatch(text); m != nil {
+			resource, stack = m[1], nil
+			continue
+		}
+		m := bicepPropertyRx.FindStringSubmatchIndex(text)
+		if m == nil {
+			continue
+		}
+		indent, key, value := m[3]-m[2], text[m[4]:m[5]], text[m[6]:m[7]]
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		if value == "{" {
+			stack = append(stack, frame{indent: indent, key: key})
+			continue
+		}
+		if resource == "" || len(value) < 6 || value[0] != '\'' || value[len(value)-1] != '\'' {
+			continue
+		}
+		literal := value[1 : len(value)-1]
+		if strings.Contains(literal, "${") || !iacCredentialKey(key) {
+			continue
+		}
+		parts := []string{resource}
+		for _, f := range stack {
+			parts = append(parts, f.key)
+		}
+		parts = append(parts, key)
+		findings = append(findings, iacFinding(path, line, m[6]+2, DetectorBicepLiteral, "bicep", strings.Join(parts, "."), literal,
+			"literal credential in resource property", 0.8))
+	}
+	return findings
+}
+
+// --- Pulumi ---
+
+// inspectPulumiConfig flags plaintext values for sensitive keys in a
+// Pulumi.<stack>.yaml config; values set with --secret are stored as
+// {secure: ...} and are skipped.
+func inspectPulumiConfig(path string, data []byte) []types.Finding {
+	doc := parseYAMLDocument(data)
+	cfg := mappingValue(doc, "config")
+	if cfg == nil || cfg.Kind != yaml.MappingNode {
+		return nil
+	}
+	var findings []types.Finding
+	for i := 0; i+1 < len(cfg.Content); i += 2 {
+		name, v := cfg.Content[i].Value, cfg.Content[i+1]
+		if mappingValue(v, "secure") != nil {
+			continue
+		}
+		walkYAMLScalars(v, "", "", func(p, key string, val *yaml.Node) {
+			if key == "" {
+				key = name[strings.LastIndex(name, ":")+1:]
+			}
+			if !yamlLiteralString(val) || !iacCredentialKey(key) {
+				return
+			}
+			address := "config." + name
+			if p != "" {
+				address += "." + p
+			}
+			findings = append(findings, iacFinding(path, val.Line, yamlValueColumn(val), DetectorPulumiPlaintext, "pulumi", address, val.Value,
+				"sensitive config value is not stored as a Pulumi secret", 0.8))
+		})
+	}
+	return findings
+}
+
+// inspectPulumiExport flags secrets that a `pulumi stack export
+// --show-secrets` wrote out in plaintext.
+func inspectPulumiExport(path string, data []byte) []types.Finding {
+	doc := parseYAMLDocument(data)
+	deployment := mappingValue(doc, "deployment")
+	if deployment == nil {
+		deployment = mappingValue(doc, "checkpoint")
+		deployment = mappingValue(deployment, "latest")
+	}
+	var findings []types.Finding
+	for _, r := range yamlSequence(mappingValue(deployment, "resources")) {
+		id := ""
+		if urn := mappingValue(r, "urn"); urn != nil {
+			id = urn.Value
+			if i := strings.LastIndex(id, "::"); i >= 0 {
+				id = id[i+2:]
+			}
+		}
+		for _, section := range []string{"inputs", "outputs"} {
+			walkPulumiSecrets(mappingValue(r, section), id+"."+section, func(address string, val *yaml.Node) {
+				value := val.Value
+				if s, err := strconv.Unquote(value); err == nil {
+					value = s
+				}
+				findings = append(findings, iacFinding(path, val.Line, yamlValueColumn(val), DetectorPulumiPlaintext, "pulumi", address, value,
+					"Pulumi secret exported in plaintext", 0.9))
+			})
+		}
+	}
+	return findings
+}
+
+func walkPulumiSecrets(n *yaml.Node, address string, fn func(address string, val *yaml.Node)) {
+	if n == nil {
+		return
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		if sig := mappingValue(n, pulumiSecretSig); sig != nil {
+			if pt := mappingValue(n, "plaintext"); pt != nil && pt.Kind == yaml.ScalarNode && pt.Value != "" {
+				fn(address, pt)
+			}
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			walkPulumiSecrets(n.Content[i+1], address+"."+n.Content[i].Value, fn)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			walkPulumiSecrets(c, address+"["+strconv.Itoa(i)+"]", fn)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/varalys/redactyl/internal/types"
+)
+
+func TestInspectCloudIaC_CloudFormationYAML(t *testing.T) {
+	tmpl := `AWSTemplateFormatVersion: "2010-09-09"
+Parameters:
+  DBPassword:
+    Type: String
+    NoEcho: true
+    Default: Sup3rS3cret!
+  ApiToken:
+    Type: String
+    Default: "tok-123456"
+  Env:
+    Type: String
+    Default: production
+Resources:
+  MyDB:
+    Type: AWS::RDS::DBInstance
+    Properties:
+      MasterUsername: admin
+      MasterUserPassword: hunter2hunter2
+      SecretName: prod/db
+  Other:
+    Type: AWS::RDS::DBInstance
+    Properties:
+      MasterUserPassword: !Ref DBPassword
+      ResolvedPassword: "{{resolve:secretsmanager:db}}"
+`
+	got := findingsByAddress(InspectCloudIaC("stack.yaml", []byte(tmpl)))
+	require.Len(t, got, 3, "got %v", got)
+
+	noEcho := got["Parameters.DBPassword.Default"]
+	assert.Equal(t, 6, noEcho.Line)
+	assert.Equal(t, 14, noEcho.Column)
+	assert.Equal(t, types.SevHigh, noEcho.Severity)
+	assert.Contains(t, noEcho.Context, "NoEcho")
+
+	token := got["Parameters.ApiToken.Default"]
+	assert.Equal(t, "tok-123456", token.Match)
+	assert.Equal(t, 15, token.Column, "column skips the opening quote")
+
+	pw := got["MyDB.Properties.MasterUserPassword"]
+	assert.Equal(t, 18, pw.Line)
+	assert.Equal(t, DetectorCloudFormationLiteral, pw.Detector)
+	assert.Equal(t, "cloudformation", pw.Metadata["iac_kind"])
+}
+
+func TestInspectCloudIaC_CloudFormationJSON(t *testing.T) {
+	tmpl := `{
+  "Resources": {
+    "Queue": {
+      "Type": "AWS::AmazonMQ::Broker",
+      "Properties": {
+        "Users": [{"Username": "admin", "Password": "broker-pass-1"}]
+      }
+    }
+  }
+}`
+	got := findingsByAddress(InspectCloudIaC("template.json", []byte(tmpl)))
+	require.Len(t, got, 1)
+	f := got["Queue.Properties.Users[0].Password"]
+	assert.Equal(t, 6, f.Line)
+	assert.Equal(t, "broker-pass-1", f.Match)
+}
+
+func TestInspectCloudIaC_ARM(t *testing.T) {
+	tmpl := `{
+  "$schema": "https://schema.management.azure.com/schemas/2019-04-01/deploymentTemplate.json#",
+  "parameters": {
+    "adminPassword": {"type": "securestring", "defaultValue": "P@ssw0rd1234"},
+    "location": {"type": "string", "defaultValue": "[resourceGroup().location]"}
+  },
+  "resources": [
+    {
+      "type": "Microsoft.Sql/servers",
+      "name": "sqlserver",
+      "properties": {
+        "administratorLoginPassword": "literal-password",
+        "administratorLogin": "[parameters('adminLogin')]"
+      },
+      "resources": [
+        {"type": "databases", "name": "[concat('db', '1')]", "properties": {"connectionString": "Server=x;Password=y"}}
+      ]
+    }
+  ]
+}`
+	got := findingsByAddress(InspectCloudIaC("azuredeploy.json", []byte(tmpl)))
+	require.Len(t, got, 3, "got %v", got)
+	assert.Equal(t, 4, got["parameters.adminPassword.defaultValue"].Line)
+	assert.Equal(t, types.SevHigh, got["parameters.adminPassword.defaultValue"].Severity)
+	assert.Equal(t, 12, got["sqlserver.properties.administratorLoginPassword"].Line)
+	assert.Contains(t, got, "sqlserver.resources[0].properties.connectionString")
+
+	params := `{
+  "$schema": "https://schema.management.azure.com/schemas/2019-04-01/deploymentParameters.json#",
+  "parameters": {
+    "adminPassword": {"value": "param-file-secret"},
+    "kvPassword": {"reference": {"keyVault": {"id": "/subscriptions/x"}, "secretName": "pw"}}
+  }
+}`
+	got = findingsByAddress(InspectCloudIaC("azuredeploy.parameters.json", []byte(params)))
+	require.Len(t, got, 1)
+	assert.Equal(t, "param-file-secret", got["parameters.adminPassword.value"].Match)
+}
+
+func TestInspectCloudIaC_Bicep(t *testing.T) {
+	src := `@secure()
+param adminPassword string = 'Sup3rS3cret!'
+param location string = resourceGroup().location
+param apiKey string = 'key-1234567'
+
+resource sqlServer 'Microsoft.Sql/servers@2021-11-01' = {
+  name: 'sql-${uniqueString(resourceGroup().id)}'
+  properties: {
+    administratorLogin: 'admin'
+    administratorLoginPassword: 'literal-password'
+  }
+}
+`
+	got := findingsByAddress(InspectCloudIaC("main.bicep", []byte(src)))
+	require.Len(t, got, 3, "got %v", got)
+	assert.Equal(t, types.SevHigh, got["param.adminPassword"].Severity)
+	assert.Equal(t, 2, got["param.adminPassword"].Line)
+	assert.Equal(t, 31, got["param.adminPassword"].Column)
+	assert.Equal(t, 4, got["param.apiKey"].Line)
+	pw := got["sqlServer.properties.administratorLoginPassword"]
+	assert.Equal(t, 10, pw.Line)
+	assert.Equal(t, 34, pw.Column)
+	assert.Equal(t, "literal-password", pw.Match)
+}
+
+func TestInspectCloudIaC_Pulumi(t *testing.T) {
+	cfg := `config:
+  aws:region: us-east-1
+  app:dbPassword:
+    secure: AAABAKm2b3VfZXhhbXBsZQ==
+  app:apiToken: plaintext-token
+`
+	got := findingsByAddress(InspectCloudIaC("Pulumi.dev.yaml", []byte(cfg)))
+	require.Len(t, got, 1)
+	assert.Equal(t, 5, got["config.app:apiToken"].Line)
+
+	export := `{
+  "version": 3,
+  "deployment": {
+    "resources": [
+      {
+        "urn": "urn:pulumi:dev::app::aws:rds/instance:Instance::db",
+        "inputs": {
+          "password": {"4dabf18193072939515e22adb298388d": "1b47061264138c4ac30d75fd1eb44270", "plaintext": "\"hunter2hunter2\""},
+          "username": "admin"
+        },
+        "outputs": {
+          "password": {"4dabf18193072939515e22adb298388d": "1b47061264138c4ac30d75fd1eb44270", "ciphertext": "v1:abc"}
+        }
+      }
+    ]
+  }
+}`
+	got = findingsByAddress(InspectCloudIaC("stack.json", []byte(export)))
+	require.Len(t, got, 1)
+	f := got["db.inputs.password"]
+	assert.Equal(t, "hunter2hunter2", f.Match)
+	assert.Equal(t, 8, f.Line)
+	assert.Equal(t, DetectorPulumiPlaintext, f.Detector)
+}
+
+func TestScanIaCWithFindings_CloudTemplates(t *testing.T) {
+	dir := t.TempDir()
+	cfn := "AWSTemplateFormatVersion: \"2010-09-09\"\nParameters:\n  Password:\n    Type: String\n    NoEcho: true\n    Default: hunter2hunter2\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stack.yaml"), []byte(cfn), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "values.yaml"), []byte("password: not-a-template\n"), 0644))
+
+	lim := Limits{MaxArchiveBytes: 1 << 20, MaxEntries: 100, MaxDepth: 1, TimeBudget: time.Second}
+	var got []types.Finding
+	err := ScanIaCWithFindings(dir, lim, nil, func(string, []byte) {}, nil, func(f types.Finding) { got = append(got, f) })
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "stack.yaml", got[0].Path)
+	assert.Equal(t, "Parameters.Password.Default", got[0].Metadata["iac_address"])
+}
@@ -0,0 +1,540 @@
+package artifacts
+
+import (
+	"bytes"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/varalys/redactyl/internal/types"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Detector IDs for structural findings raised on Dockerfiles and compose files.
+const (
+	DetectorDockerBuildArg       = "docker-build-arg-secret"
+	DetectorDockerEnv            = "docker-env-secret"
+	DetectorDockerRunCredential  = "docker-run-inline-credential"
+	DetectorDockerPrivateKeyCopy = "docker-private-key-copied"
+	DetectorDockerCredentialCopy = "docker-credential-file-copied"
+	DetectorComposeInlineSecret  = "compose-inline-secret"
+)
+
+// IsDockerfilePath reports whether path names a Dockerfile or Containerfile
+// (including variants such as Dockerfile.prod and app.dockerfile).
+func IsDockerfilePath(p string) bool {
+	base := strings.ToLower(filepath.Base(p))
+	return base == "dockerfile" || base == "containerfile" ||
+		strings.HasPrefix(base, "dockerfile.") || strings.HasPrefix(base, "containerfile.") ||
+		strings.HasSuffix(base, ".dockerfile") || strings.HasSuffix(base, ".containerfile")
+}
+
+// IsComposePath reports whether path names a Docker Compose file such as
+// docker-compose.yml, compose.yaml or docker-compose.override.yml.
+func IsComposePath(p string) bool {
+	base := strings.ToLower(filepath.Base(p))
+	ext := filepath.Ext(base)
+	if ext != ".yml" && ext != ".yaml" {
+		return false
+	}
+	return strings.HasPrefix(base, "docker-compose") || base == "compose"+ext || strings.HasPrefix(base, "compose.")
+}
+
+// InspectDocker analyses a Dockerfile or compose file. Every ARG, ENV and
+// LABEL value (and every compose environment entry and build arg) is
+// returned as a positioned extract for the detectors, with its field in the
+// "iac_address" metadata key. Structural findings cover literal credentials
+// in sensitive build args and environment variables, inline credentials in
+// RUN commands, and private keys or credential files copied into the image.
+func InspectDocker(p string, data []byte) ([]Extract, []types.Finding) {
+	if len(data) == 0 || len(data) > cloudTemplateMaxBytes {
+		return nil, nil
+	}
+	switch {
+	case IsDockerfilePath(p):
+		return inspectDockerfile(p, data)
+	case IsComposePath(p):
+		return inspectCompose(p, data)
+	}
+	return nil, nil
+}
+
+// --- Dockerfile ---
+
+// dockerInstr is one logical Dockerfile instruction with line continuations
+// joined. pos maps every byte of args to its offset in the source.
+type dockerInstr struct {
+	cmd  string
+	args string
+	pos  []int
+}
+
+func (in *dockerInstr) appendSeg(seg []byte, off int) {
+	in.args += string(seg)
+	for i := range seg {
+		in.pos = append(in.pos, off+i)
+	}
+}
+
+// parseDockerfile splits data into instructions, honouring the escape
+// parser directive, comment lines inside continuations and heredocs.
+func parseDockerfile(data []byte) []dockerInstr {
+	offs := lineOffsets(data)
+	lineAt := func(ln int) ([]byte, int) {
+		start := offs[ln]
+		end := len(data)
+		if ln+1 < len(offs) {
+			end = offs[ln+1] - 1
+		}
+		return bytes.TrimSuffix(data[start:end], []byte("\r")), start
+	}
+	escape := byte('\\')
+	directives := true
+	var out []dockerInstr
+	var cur *dockerInstr
+	for ln := 0; ln < len(offs); ln++ {
+		raw, start := lineAt(ln)
+		trimmed := bytes.TrimLeft(raw, " \t")
+		if len(trimmed) == 0 {
+			continue
+		}
+		if trimmed[0] == '#' {
+			if directives && cur == nil {
+				d := strings.ToLower(strings.ReplaceAll(string(trimmed[1:]), " ", ""))
+				if v, ok := strings.CutPrefix(d, "escape="); ok && len(v) == 1 {
+					escape = v[0]
+				}
+			}
+			continue
+		}
+		directives = false
+		if cur == nil {
+			lead := len(raw) - len(trimmed)
+			word := trimmed
+			if i := bytes.IndexAny(trimmed, " \t"); i >= 0 {
+				word = trimmed[:i]
+			}
+			rest := bytes.TrimLeft(trimmed[len(word):], " \t")
+			cur = &dockerInstr{cmd: strings.ToUpper(string(word))}
+			cur.appendSeg(rest, start+lead+len(trimmed)-len(rest))
+		} else {
+			cur.appendSeg(raw, start)
+		}
+		body := strings.TrimRight(cur.args, " \t")
+		if strings.HasSuffix(body, string(escape)) {
+			cur.args = body[:len(body)-1]
+			cur.pos = cur.pos[:len(cur.args)]
+			continue
+		}
+		// RUN <<EOF ... EOF: the heredoc body belongs to the instruction.
+		if m := dockerHeredoc.FindStringSubmatch(cur.args); m != nil {
+			for ln+1 < len(offs) {
+				ln++
+				hraw, hstart := lineAt(ln)
+				cur.appendSeg([]byte("\n"), hstart-1)
+				cur.appendSeg(hraw, hstart)
+				if strings.TrimSpace(string(hraw)) == m[1] {
+					break
+				}
+			}
+		}
+		out = append(out, *cur)
+		cur = nil
+	}
+	if cur != nil {
+		out = append(out, *cur)
+	}
+	return out
+}
+
+var dockerHeredoc = regexp.MustCompile(`<<-?["']?([A-Za-z_][A-Za-z0-9_]*)["']?`)
+
+// dockerWord is a shell word of an instruction's arguments; start and end
+// index the raw text in args, text is the word with quotes removed.
+type dockerWord struct {
+	text       string
+	start, end int
+}
+
+func dockerWords(args string) []dockerWord {
+	var out []dockerWord
+	i := 0
+	for i < len(args) {
+		for i < len(args) && strings.IndexByte(" \t\n", args[i]) >= 0 {
+			i++
+		}
+		if i >= len(args) {
+			break
+		}
+		start := i
+		var sb strings.Builder
+		var quote byte
+	word:
+		for ; i < len(args); i++ {
+			c := args[i]
+			switch {
+			case quote != 0 && c == quote:
+				quote = 0
+			case quote != 0:
+				sb.WriteByte(c)
+			case c == '"' || c == '\'':
+				quote = c
+			case strings.IndexByte(" \t\n", c) >= 0:
+				break word
+			default:
+				sb.WriteByte(c)
+			}
+		}
+		out = append(out, dockerWord{text: sb.String(), start: start, end: i})
+	}
+	return out
+}
+
+// dockerPair is a NAME=value entry of an ARG, ENV or LABEL instruction.
+type dockerPair struct {
+	key, value string
+	keyAt      int // index of the key in args
+	valueAt    int // index of the first value character in args
+	end        int // index just past the raw value in args
+	legacy     bool
+}
+
+func dockerPairs(cmd, args string) []dockerPair {
+	words := dockerWords(args)
+	if len(words) == 0 {
+		return nil
+	}
+	// ENV NAME value with spaces (legacy form).
+	if cmd == "ENV" && !strings.Contains(args[words[0].start:words[0].end], "=") {
+		if len(words) < 2 {
+			return nil
+		}
+		rest := args[words[1].start:]
+		val := strings.TrimSpace(rest)
+		if len(words) == 2 {
+			val = words[1].text
+		}
+		at := words[1].start
+		if q := args[at]; q == '"' || q == '\'' {
+			at++
+		}
+		return []dockerPair{{key: words[0].text, value: val, keyAt: words[0].start, valueAt: at, end: len(strings.TrimRight(args, " \t\n")), legacy: true}}
+	}
+	var out []dockerPair
+	for _, w := range words {
+		raw := args[w.start:w.end]
+		eq := strings.IndexByte(raw, '=')
+		if eq <= 0 {
+			continue
+		}
+		at := w.start + eq + 1
+		if at < w.end && (args[at] == '"' || args[at] == '\'') {
+			at++
+		}
+		key, val, _ := strings.Cut(w.text, "=")
+		out = append(out, dockerPair{key: strings.Trim(key, `"'`), value: val, keyAt: w.start, valueAt: at, end: w.end})
+	}
+	return out
+}
+
+// dockerLiteral reports whether a value is a literal rather than a variable
+// reference or empty placeholder.
+func dockerLiteral(v string) bool {
+	return len(v) >= 4 && !strings.Contains(v, "$")
+}
+
+var (
+	dockerUserFlag     = regexp.MustCompile(`(?:^|\s)(?:-u|--user|--proxy-user)(?:\s+|=)["']?([^\s"':$]+):([^\s"']+)`)
+	dockerPasswordFlag = regexp.MustCompile(`(?:^|\s)--(?:http-|ftp-|proxy-)?password(?:\s+|=)["']?([^\s"']+)`)
+	dockerURLUserinfo  = regexp.MustCompile(`[A-Za-z][A-Za-z0-9+.-]*://([^\s/:@"'$]+):([^\s/@"']+)@`)
+)
+
+func inspectDockerfile(p string, data []byte) ([]Extract, []types.Finding) {
+	lines := lineOffsets(data)
+	var extracts []Extract
+	var findings []types.Finding
+	stage := ""
+	at := func(in dockerInstr, i int) (int, int) {
+		if i >= len(in.pos) {
+			i = len(in.pos) - 1
+		}
+		return offsetToLineCol(lines, in.pos[i])
+	}
+	for _, in := range parseDockerfile(data) {
+		if len(in.pos) == 0 {
+			continue
+		}
+		prefix := ""
+		if stage != "" {
+			prefix = stage + "."
+		}
+		switch in.cmd {
+		case "FROM":
+			stage = ""
+			words := dockerWords(in.args)
+			for i := 0; i+1 < len(words); i++ {
+				if strings.EqualFold(words[i].text, "as") {
+					stage = words[i+1].text
+				}
+			}
+		case "ARG", "ENV", "LABEL":
+			for _, kv := range dockerPairs(in.cmd, in.args) {
+				if kv.value == "" {
+					continue
+				}
+				addr := prefix + in.cmd + "." + kv.key
+				meta := map[string]string{"iac_address": addr, "iac_kind": "dockerfile"}
+				line, col := at(in, kv.keyAt)
+				x := Extract{Path: p, Line: line, Column: col, Metadata: meta}
+				start, end := in.pos[kv.keyAt], in.pos[kv.end-1]+1
+				switch {
+				case kv.legacy:
+					x.Data = []byte(kv.key + "=" + kv.value)
+				case end-start == kv.end-kv.keyAt:
+					x.Data = data[start:end]
+				default:
+					x.Data = []byte(in.args[kv.keyAt:kv.end])
+				}
+				extracts = append(extracts, x)
+				if in.cmd == "LABEL" || !dockerLiteral(kv.value) || !iacCredentialKey(kv.key) {
+					continue
+				}
+				vline, vcol := at(in, kv.valueAt)
+				if in.cmd == "ARG" {
+					findings = append(findings, iacFinding(p, vline, vcol, DetectorDockerBuildArg, "dockerfile", addr, kv.value,
+						"build arg default credential is recorded in the image history", 0.8))
+				} else {
+					findings = append(findings, iacFinding(p, vline, vcol, DetectorDockerEnv, "dockerfile", addr, kv.value,
+						"credential set with ENV is baked into the image", 0.9))
+				}
+			}
+		case "RUN":
+			for _, loc := range dockerRunCredentials(in.args) {
+				line, col := at(in, loc[0])
+				findings = append(findings, iacFinding(p, line, col, DetectorDockerRunCredential, "dockerfile", prefix+"RUN",
+					in.args[loc[0]:loc[1]], "inline credential in RUN command is recorded in the image history", 0.85))
+			}
+		case "COPY", "ADD":
+			for _, w := range dockerCopySources(in.args) {
+				line, col := at(in, w.start)
+				switch {
+				case dockerPrivateKeySource(w.text):
+					findings = append(findings, iacFinding(p, line, col, DetectorDockerPrivateKeyCopy, "dockerfile", prefix+in.cmd,
+						w.text, "private key copied into image", 0.9))
+				case dockerCredentialFileSource(w.text):
+					findings = append(findings, iacFinding(p, line, col, DetectorDockerCredentialCopy, "dockerfile", prefix+in.cmd,
+						w.text, "credential file copied into image", 0.8))
+				}
+			}
+		}
+	}
+	return extracts, findings
+}
+
+// dockerRunCredentials returns the [start, end) ranges in args of inline
+// credentials passed to curl/wget style flags or embedded in URLs. Values
+// taken from variables ($VAR) are not reported.
+func dockerRunCredentials(args string) [][2]int {
+	var out [][2]int
+	for _, m := range dockerUserFlag.FindAllStringSubmatchIndex(args, -1) {
+		if !strings.HasPrefix(args[m[4]:m[5]], "$") {
+			out = append(out, [2]int{m[2], m[5]})
+		}
+	}
+	for _, m := range dockerPasswordFlag.FindAllStringSubmatchIndex(args, -1) {
+		if !strings.HasPrefix(args[m[2]:m[3]], "$") {
+			out = append(out, [2]int{m[2], m[3]})
+		}
+	}
+	for _, m := range dockerURLUserinfo.FindAllStringSubmatchIndex(args, -1) {
+		if !strings.HasPrefix(args[m[4]:m[5]], "$") {
+			out = append(out, [2]int{m[2], m[5]})
+		}
+	}
+	return out
+}
+
+// dockerCopySources returns the source operands of a COPY or ADD
+// instruction in shell or JSON form, skipping flags and the destination.
+func dockerCopySources(args string) []dockerWord {
+	trimmed := strings.TrimSpace(args)
+	var words []dockerWord
+	if strings.HasPrefix(trimmed, "[") {
+		off := strings.Index(args, "[")
+		for _, w := range dockerWords(strings.NewReplacer("[", " ", "]", " ", ",", " ").Replace(args[off:])) {
+			w.start += off
+			w.end += off
+			if r := args[w.start]; r == '"' || r == '\'' {
+				w.start++
+			}
+			words = append(words, w)
+		}
+	} else {
+		for _, w := range dockerWords(args) {
+			if strings.HasPrefix(w.text, "--") {
+				continue
+			}
+			words = append(words, w)
+		}
+		// Heredoc sources (COPY <<EOF /dst) are inline content, not files.
+		if len(words) > 0 && strings.HasPrefix(words[0].text, "<<") {
+			return nil
+		}
+	}
+	if len(words) < 2 {
+		return nil
+	}
+	return words[:len(words)-1]
+}
+
+// dockerPrivateKeySource reports whether a COPY/ADD source looks like a
+// private key or an SSH directory.
+func dockerPrivateKeySource(src string) bool {
+	s := strings.ToLower(strings.TrimSuffix(filepath.ToSlash(src), "/"))
+	base := path.Base(s)
+	if strings.HasSuffix(base, ".pub") {
+		return false
+	}
+	if base == ".ssh" || strings.Contains(s, ".ssh/") {
+		return true
+	}
+	for _, k := range []string{"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519"} {
+		if strings.HasPrefix(base, k) {
+			return true
+		}
+	}
+	switch path.Ext(base) {
+	case ".pem", ".key", ".p12", ".pfx", ".ppk":
+		return true
+	}
+	return false
+}
+
+// dockerCredentialFileSource reports whether a COPY/ADD source is a file
+// that conventionally holds registry, cloud or VCS credentials.
+func dockerCredentialFileSource(src string) bool {
+	s := strings.ToLower(strings.TrimSuffix(filepath.ToSlash(src), "/"))
+	switch path.Base(s) {
+	case ".npmrc", ".pypirc", ".netrc", ".git-credentials", ".dockercfg", ".vault-token", ".env", ".htpasswd":
+		return true
+	}
+	return strings.HasSuffix(s, ".aws/credentials") || strings.HasSuffix(s, ".docker/config.json") ||
+		strings.HasSuffix(s, ".kube/config") || s == ".aws" || s == ".kube"
+}
+
+// --- Compose ---
+
+func inspectCompose(p string, data []byte) ([]Extract, []types.Finding) {
+	doc := parseYAMLDocument(data)
+	if doc == nil {
+		return nil, nil
+	}
+	services := yamlResolve(mappingValue(doc, "services"))
+	if services == nil || services.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	offs := lineOffsets(data)
+	var extracts []Extract
+	var findings []types.Finding
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		name := services.Content[i].Value
+		svc := yamlResolve(services.Content[i+1])
+		if svc == nil || svc.Kind != yaml.MappingNode {
+			continue
+		}
+		addr := "services." + name
+		if env := composeField(svc, "environment"); env != nil {
+			x, f := inspectComposeVars(p, data, offs, env, addr+".environment")
+			extracts = append(extracts, x...)
+			findings = append(findings, f...)
+		}
+		if build := composeField(svc, "build"); build != nil && build.Kind == yaml.MappingNode {
+			if args := composeField(build, "args"); args != nil {
+				x, f := inspectComposeVars(p, data, offs, args, addr+".build.args")
+				extracts = append(extracts, x...)
+				findings = append(findings, f...)
+			}
+		}
+	}
+	return extracts, findings
+}
+
+// yamlResolve follows an alias to the node it refers to.
+func yamlResolve(n *yaml.Node) *yaml.Node {
+	for n != nil && n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	return n
+}
+
+// composeField looks up key in m, falling back to mappings merged in with
+// "<<: *anchor" as compose files commonly share settings that way.
+func composeField(m *yaml.Node, key string) *yaml.Node {
+	if v := yamlResolve(mappingValue(m, key)); v != nil {
+		return v
+	}
+	merge := yamlResolve(mappingValue(m, "<<"))
+	if merge == nil {
+		return nil
+	}
+	sources := []*yaml.Node{merge}
+	if merge.Kind == yaml.SequenceNode {
+		sources = merge.Content
+	}
+	for _, s := range sources {
+		if s = yamlResolve(s); s != nil && s.Kind == yaml.MappingNode {
+			if v := composeField(s, key); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// inspectComposeVars handles an environment or build args block in either
+// mapping (KEY: value) or list ("KEY=value") form.
+func inspectComposeVars(p string, data []byte, offs []int, n *yaml.Node, addr string) ([]Extract, []types.Finding) {
+	var extracts []Extract
+	var findings []types.Finding
+	add := func(key, value string, x Extract, vline, vcol int, literal bool) {
+		a := addr + "." + key
+		x.Path = p
+		x.Metadata = map[string]string{"iac_address": a, "iac_kind": "compose"}
+		extracts = append(extracts, x)
+		if literal && dockerLiteral(value) && iacCredentialKey(key) {
+			findings = append(findings, iacFinding(p, vline, vcol, DetectorComposeInlineSecret, "compose", a, value,
+				"inline secret in compose file", 0.8))
+		}
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], yamlResolve(n.Content[i+1])
+			if v == nil || v.Kind != yaml.ScalarNode || v.Tag == "!!null" || v.Value == "" || k.Line < 1 || k.Line > len(offs) {
+				continue
+			}
+			start := offs[k.Line-1] + k.Column - 1
+			_, end, ok := yamlValueSpan(data, offs, k, v)
+			if !ok {
+				continue
+			}
+			x := Extract{Data: bytes.TrimRight(data[start:end], " \t\r\n"), Line: k.Line, Column: k.Column}
+			add(k.Value, v.Value, x, v.Line, yamlValueColumn(v), v.Tag == "!!str")
+		}
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			if item.Kind != yaml.ScalarNode {
+				continue
+			}
+			key, value, ok := strings.Cut(item.Value, "=")
+			if !ok || value == "" {
+				continue
+			}
+			col := yamlValueColumn(item)
+			x := Extract{Data: []byte(item.Value), Line: item.Line, Column: col}
+			add(key, value, x, item.Line, col+len(key)+1, true)
+		}
+	}
+	return extracts, findings
+}
@@ -0,0 +1,581 @@
+package artifacts
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/varalys/redactyl/internal/types"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Encryption formats recognised by InspectEncrypted. The value is stored in
+// the "encryption" metadata key of structural findings.
+const (
+	EncryptionSOPS         = "sops"
+	EncryptionSealedSecret = "sealed-secret"
+	EncryptionAnsibleVault = "ansible-vault"
+)
+
+// Detector IDs for structural findings raised on encrypted-secret files.
+const (
+	DetectorSOPSPlaintext       = "sops-plaintext-value"
+	DetectorSOPSDecrypted       = "sops-decrypted-file"
+	DetectorSealedPlaintext     = "sealed-secret-plaintext"
+	DetectorVaultMissingHeader  = "ansible-vault-missing-header"
+	DetectorVaultPlaintextValue = "ansible-vault-plaintext"
+)
+
+var (
+	sopsValueRx = regexp.MustCompile(`ENC\[[A-Za-z0-9_]+,data:[^\]]*\]`)
+	armoredRx   = regexp.MustCompile(`(?s)-----BEGIN (?:AGE ENCRYPTED FILE|PGP MESSAGE)-----(.*?)-----END (?:AGE ENCRYPTED FILE|PGP MESSAGE)-----`)
+	vaultHexRx  = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+)
+
+// InspectEncrypted recognises SOPS files, Bitnami SealedSecrets and Ansible
+// Vault files. It returns the content with properly encrypted values masked
+// (line and column layout is preserved) so detectors do not report
+// ciphertext, together with structural findings for files that claim to be
+// encrypted but carry plaintext values. Unrecognised content is returned
+// unchanged with no findings.
+func InspectEncrypted(path string, data []byte) ([]byte, []types.Finding) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	switch {
+	case bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("$ANSIBLE_VAULT;")):
+		return inspectVaultFile(path, data)
+	case isSOPSEnv(data):
+		return inspectSOPSEnv(path, data)
+	}
+	if masked, findings, ok := inspectSOPSDocument(path, data); ok {
+		return masked, findings
+	}
+	if isSOPSNamed(path) {
+		return data, []types.Finding{encryptedFinding(path, 1, 1, DetectorSOPSDecrypted, EncryptionSOPS, filepath.Base(path),
+			"file is named as SOPS-encrypted but has no sops metadata (committed decrypted?)", 0.9, nil)}
+	}
+	if bytes.Contains(data, []byte("SealedSecret")) {
+		if masked, findings, ok := inspectSealedSecrets(path, data); ok {
+			return masked, findings
+		}
+	}
+	if bytes.Contains(data, []byte("!vault")) {
+		if masked, ok := maskInlineVault(data); ok {
+			return masked, nil
+		}
+	}
+	if isVaultPath(path) {
+		return data, []types.Finding{encryptedFinding(path, 1, 1, DetectorVaultMissingHeader, EncryptionAnsibleVault, filepath.Base(path),
+			"Ansible vault file is missing the $ANSIBLE_VAULT header (stored in plaintext)", 0.75, nil)}
+	}
+	return data, nil
+}
+
+func encryptedFinding(path string, line, col int, detector, kind, match, desc string, confidence float64, meta map[string]string) types.Finding {
+	sev := types.SevHigh
+	if confidence < 0.9 {
+		sev = types.SevMed
+	}
+	md := map[string]string{"encryption": kind}
+	for k, v := range meta {
+		md[k] = v
+	}
+	return types.Finding{
+		Path:       path,
+		Line:       line,
+		Column:     col,
+		Match:      match,
+		Detector:   detector,
+		Severity:   sev,
+		Confidence: confidence,
+		Context:    desc,
+		Metadata:   md,
+	}
+}
+
+// maskBytes overwrites every byte in b[start:end] except line breaks so that
+// the masked content keeps its original line and column layout.
+func maskBytes(b []byte, start, end int) {
+	if start < 0 {
+		start = 0
+	}
+	if end > len(b) {
+		end = len(b)
+	}
+	for i := start; i < end; i++ {
+		if b[i] != '\n' && b[i] != '\r' {
+			b[i] = '*'
+		}
+	}
+}
+
+// maskSOPSCiphertext masks the payload of ENC[...] values and armored
+// age/PGP blocks, keeping the surrounding markers readable.
+func maskSOPSCiphertext(b []byte) {
+	for _, loc := range sopsValueRx.FindAllIndex(b, -1) {
+		maskBytes(b, loc[0]+len("ENC["), loc[1]-1)
+	}
+	for _, loc := range armoredRx.FindAllSubmatchIndex(b, -1) {
+		maskBytes(b, loc[2], loc[3])
+	}
+}
+
+// maskYAMLValue masks the value of a mapping entry, including continuation
+// lines of multi-line and block scalars.
+func maskYAMLValue(b []byte, offs []int, key, val *yaml.Node) {
+	if start, end, ok := yamlValueSpan(b, offs, key, val); ok {
+		maskBytes(b, start, end)
+	}
+}
+
+// yamlValueSpan returns the byte range of a mapping entry's value in b. The
+// range runs up to the next line indented at or left of the key, so it
+// covers continuation lines of multi-line and block scalars.
+func yamlValueSpan(b []byte, offs []int, key, val *yaml.Node) (int, int, bool) {
+	if val.Line < 1 || val.Line > len(offs) {
+		return 0, 0, false
+	}
+	start := offs[val.Line-1] + val.Column - 1
+	end := len(b)
+	for ln := val.Line; ln < len(offs); ln++ {
+		next := offs[ln]
+		rest := b[next:]
+		if nl := bytes.IndexByte(rest, '\n'); nl >= 0 {
+			rest = rest[:nl]
+		}
+		trimmed := bytes.TrimLeft(rest, " \t")
+		indent := len(rest) - len(trimmed)
+		if len(bytes.TrimSpace(trimmed)) > 0 && indent < key.Column {
+			end = next
+			break
+		}
+	}
+	if start > end {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// --- SOPS ---
+
+type sopsRules struct {
+	unencryptedSuffix string
+	encryptedSuffix   string
+	unencryptedRegex  *regexp.Regexp
+	encryptedRegex    *regexp.Regexp
+}
+
+// plainByDefault reports whether keys are left unencrypted unless they match
+// encrypted_suffix or encrypted_regex.
+func (r sopsRules) plainByDefault() bool {
+	return r.encryptedSuffix != "" || r.encryptedRegex != nil
+}
+
+// unencrypted reports whether key (and its subtree) is excluded from
+// encryption by unencrypted_suffix or unencrypted_regex.
+func (r sopsRules) unencrypted(key string) bool {
+	if r.unencryptedSuffix != "" && strings.HasSuffix(key, r.unencryptedSuffix) {
+		return true
+	}
+	return r.unencryptedRegex != nil && r.unencryptedRegex.MatchString(key)
+}
+
+// encrypted reports whether key (and its subtree) is selected for
+// encryption by encrypted_suffix or encrypted_regex.
+func (r sopsRules) encrypted(key string) bool {
+	if r.encryptedSuffix != "" && strings.HasSuffix(key, r.encryptedSuffix) {
+		return true
+	}
+	return r.encryptedRegex != nil && r.encryptedRegex.MatchString(key)
+}
+
+func sopsRulesFrom(get func(string) string) sopsRules {
+	r := sopsRules{
+		unencryptedSuffix: get("unencrypted_suffix"),
+		encryptedSuffix:   get("encrypted_suffix"),
+	}
+	if s := get("unencrypted_regex"); s != "" {
+		r.unencryptedRegex, _ = regexp.Compile(s)
+	}
+	if s := get("encrypted_regex"); s != "" {
+		r.encryptedRegex, _ = regexp.Compile(s)
+	}
+	return r
+}
+
+func isSOPSNamed(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	if base == ".sops.yaml" || base == ".sops.yml" {
+		return false // SOPS creation rules, not an encrypted file
+	}
+	for _, infix := range []string{".sops.", ".enc."} {
+		if i := strings.Index(base, infix); i > 0 {
+			switch filepath.Ext(base) {
+			case ".yaml", ".yml", ".json", ".env", ".ini":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inspectSOPSDocument handles YAML and JSON SOPS files. JSON is parsed with
+// the YAML decoder to obtain line information for plaintext values.
+func inspectSOPSDocument(path string, data []byte) ([]byte, []types.Finding, bool) {
+	if !bytes.Contains(data, []byte("sops")) {
+		return nil, nil, false
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil || len(root.Content) == 0 {
+		return nil, nil, false
+	}
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return nil, nil, false
+	}
+	var meta *yaml.Node
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value == "sops" && doc.Content[i+1].Kind == yaml.MappingNode {
+			meta = doc.Content[i+1]
+		}
+	}
+	if meta == nil {
+		return nil, nil, false
+	}
+	metaValues := map[string]string{}
+	for i := 0; i+1 < len(meta.Content); i += 2 {
+		if meta.Content[i+1].Kind == yaml.ScalarNode {
+			metaValues[meta.Content[i].Value] = meta.Content[i+1].Value
+		}
+	}
+	if metaValues["mac"] == "" && metaValues["version"] == "" && metaValues["lastmodified"] == "" {
+		return nil, nil, false
+	}
+	rules := sopsRulesFrom(func(k string) string { return metaValues[k] })
+
+	var findings []types.Finding
+	// decided is set once a key on the path matched a suffix/regex rule; SOPS
+	// applies that decision to the whole subtree.
+	var walk func(n *yaml.Node, keyPath []string, plain, decided bool)
+	walk = func(n *yaml.Node, keyPath []string, plain, decided bool) {
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				k := n.Content[i].Value
+				if len(keyPath) == 0 && k == "sops" {
+					continue
+				}
+				childPlain, childDecided := plain, decided
+				if !decided {
+					if rules.unencrypted(k) {
+						childPlain, childDecided = true, true
+					} else if rules.encrypted(k) {
+						childPlain, childDecided = false, true
+					}
+				}
+				walk(n.Content[i+1], append(append([]string(nil), keyPath...), k), childPlain, childDecided)
+			}
+		case yaml.SequenceNode:
+			for _, c := range n.Content {
+				walk(c, keyPath, plain, decided)
+			}
+		case yaml.ScalarNode:
+			if n.Value == "" || n.Tag == "!!null" || sopsValueRx.MatchString(n.Value) || len(keyPath) == 0 {
+				return
+			}
+			key := keyPath[len(keyPath)-1]
+			meta := map[string]string{"key": strings.Join(keyPath, ".")}
+			if plain {
+				if !keyLooksSensitive(strings.TrimSuffix(key, rules.unencryptedSuffix)) {
+					return
+				}
+				findings = append(findings, encryptedFinding(path, n.Line, n.Column, DetectorSOPSPlaintext, EncryptionSOPS, n.Value,
+					"sensitive key is left unencrypted by the file's sops rules (unencrypted_suffix/regex misuse)", 0.9, meta))
+				return
+			}
+			findings = append(findings, encryptedFinding(path, n.Line, n.Column, DetectorSOPSPlaintext, EncryptionSOPS, n.Value,
+				"plaintext value in a SOPS-encrypted file (edited after encryption?)", 0.9, meta))
+		}
+	}
+	walk(doc, nil, rules.plainByDefault(), false)
+
+	masked := append([]byte(nil), data...)
+	maskSOPSCiphertext(masked)
+	return masked, findings, true
+}
+
+func isSOPSEnv(data []byte) bool {
+	return bytes.Contains(data, []byte("sops_version=")) || bytes.Contains(data, []byte("sops_mac="))
+}
+
+func inspectSOPSEnv(path string, data []byte) ([]byte, []types.Finding) {
+	type kv struct {
+		key, value string
+		line, col  int
+	}
+	var entries []kv
+	metaValues := map[string]string{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	line := 0
+	for sc.Scan() {
+		line++
+		t := sc.Text()
+		trimmed := strings.TrimSpace(t)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		eq := strings.Index(t, "=")
+		if eq <= 0 {
+			continue
+		}
+		key := strings.TrimSpace(t[:eq])
+		val := t[eq+1:]
+		if strings.HasPrefix(key, "sops_") {
+			metaValues[strings.TrimPrefix(key, "sops_")] = val
+			continue
+		}
+		entries = append(entries, kv{key: key, value: val, line: line, col: eq + 2})
+	}
+	rules := sopsRulesFrom(func(k string) string { return metaValues[k] })
+
+	var findings []types.Finding
+	for _, e := range entries {
+		if e.value == "" || sopsValueRx.MatchString(e.value) {
+			continue
+		}
+		meta := map[string]string{"key": e.key}
+		if rules.unencrypted(e.key) || (rules.plainByDefault() && !rules.encrypted(e.key)) {
+			if keyLooksSensitive(strings.TrimSuffix(e.key, rules.unencryptedSuffix)) {
+				findings = append(findings, encryptedFinding(path, e.line, e.col, DetectorSOPSPlaintext, EncryptionSOPS, e.value,
+					"sensitive key is left unencrypted by the file's sops rules (unencrypted_suffix/regex misuse)", 0.9, meta))
+			}
+			continue
+		}
+		findings = append(findings, encryptedFinding(path, e.line, e.col, DetectorSOPSPlaintext, EncryptionSOPS, e.value,
+			"plaintext value in a SOPS-encrypted file (edited after encryption?)", 0.9, meta))
+	}
+
+	masked := append([]byte(nil), data...)
+	maskSOPSCiphertext(masked)
+	offs := lineOffsets(masked)
+	for i, start := range offs {
+		end := len(masked)
+		if i+1 < len(offs) {
+			end = offs[i+1]
+		}
+		if bytes.HasPrefix(masked[start:end], []byte("sops_")) {
+			if eq := bytes.IndexByte(masked[start:end], '='); eq >= 0 {
+				maskBytes(masked, start+eq+1, end)
+			}
+		}
+	}
+	return masked, findings
+}
+
+// --- Sealed Secrets ---
+
+func inspectSealedSecrets(path string, data []byte) ([]byte, []types.Finding, bool) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	var masked []byte
+	var offs []int
+	var findings []types.Finding
+	found := false
+	for {
+		var root yaml.Node
+		err := dec.Decode(&root)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			break
+		}
+		if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+		doc := root.Content[0]
+		if kind := mappingValue(doc, "kind"); kind == nil || kind.Value != "SealedSecret" {
+			continue
+		}
+		if !found {
+			found = true
+			masked = append([]byte(nil), data...)
+			offs = lineOffsets(masked)
+		}
+		name := ""
+		if md := mappingValue(doc, "metadata"); md != nil {
+			if n := mappingValue(md, "name"); n != nil {
+				name = n.Value
+			}
+		}
+		report := func(keyPath string, n *yaml.Node, desc string) {
+			meta := map[string]string{"key": keyPath}
+			if name != "" {
+				meta["k8s_name"] = name
+			}
+			findings = append(findings, encryptedFinding(path, n.Line, n.Column, DetectorSealedPlaintext, EncryptionSealedSecret, n.Value, desc, 0.9, meta))
+		}
+		spec := mappingValue(doc, "spec")
+		if spec != nil {
+			if enc := mappingValue(spec, "encryptedData"); enc != nil && enc.Kind == yaml.MappingNode {
+				for i := 0; i+1 < len(enc.Content); i += 2 {
+					k, v := enc.Content[i], enc.Content[i+1]
+					if v.Kind != yaml.ScalarNode || v.Value == "" {
+						continue
+					}
+					if looksSealed(v.Value) {
+						maskYAMLValue(masked, offs, k, v)
+						continue
+					}
+					report("spec.encryptedData."+k.Value, v, "encryptedData value is not sealed ciphertext (plaintext or plain base64)")
+				}
+			}
+			if tmpl := mappingValue(spec, "template"); tmpl != nil {
+				for _, field := range []string{"data", "stringData"} {
+					reportPlainMap(mappingValue(tmpl, field), "spec.template."+field, report)
+				}
+			}
+		}
+		for _, field := range []string{"data", "stringData"} {
+			reportPlainMap(mappingValue(doc, field), field, report)
+		}
+	}
+	if !found {
+		return nil, nil, false
+	}
+	return masked, findings, true
+}
+
+func reportPlainMap(m *yaml.Node, prefix string, report func(string, *yaml.Node, string)) {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		v := m.Content[i+1]
+		if v.Kind != yaml.ScalarNode || v.Value == "" || strings.Contains(v.Value, "{{") {
+			continue
+		}
+		report(prefix+"."+m.Content[i].Value, v, "plaintext secret data in a SealedSecret")
+	}
+}
+
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// looksSealed reports whether s is plausibly kubeseal output: base64 of a
+// length-prefixed RSA-wrapped session key followed by AES-GCM ciphertext.
+func looksSealed(s string) bool {
+	s = strings.Join(strings.Fields(s), "")
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return false
+	}
+	if len(raw) < 128 {
+		return false
+	}
+	printable := 0
+	for _, c := range raw {
+		if c >= 0x20 && c < 0x7f {
+			printable++
+		}
+	}
+	return printable*100/len(raw) < 90
+}
+
+// --- Ansible Vault ---
+
+func inspectVaultFile(path string, data []byte) ([]byte, []types.Finding) {
+	masked := append([]byte(nil), data...)
+	offs := lineOffsets(masked)
+	header := -1
+	for i, start := range offs {
+		end := len(masked)
+		if i+1 < len(offs) {
+			end = offs[i+1]
+		}
+		line := bytes.TrimSpace(masked[start:end])
+		if header < 0 {
+			if bytes.HasPrefix(line, []byte("$ANSIBLE_VAULT;")) {
+				header = i
+			}
+			continue
+		}
+		if len(line) == 0 {
+			continue
+		}
+		if !vaultHexRx.Match(line) {
+			return data, []types.Finding{encryptedFinding(path, i+1, 1, DetectorVaultPlaintextValue, EncryptionAnsibleVault, filepath.Base(path),
+				"Ansible vault file has a vault header but its body is not vault ciphertext", 0.9, nil)}
+		}
+		maskBytes(masked, start, end)
+	}
+	return masked, nil
+}
+
+// maskInlineVault masks `!vault |` tagged values embedded in YAML files.
+func maskInlineVault(data []byte) ([]byte, bool) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, false
+	}
+	masked := append([]byte(nil), data...)
+	offs := lineOffsets(masked)
+	found := false
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		switch n.Kind {
+		case yaml.DocumentNode, yaml.SequenceNode:
+			for _, c := range n.Content {
+				walk(c)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				v := n.Content[i+1]
+				if v.Tag == "!vault" && strings.HasPrefix(strings.TrimSpace(v.Value), "$ANSIBLE_VAULT;") {
+					if body := strings.SplitN(strings.TrimSpace(v.Value), "\n", 2); len(body) == 2 {
+						if _, err := hex.DecodeString(strings.Join(strings.Fields(body[1]), "")); err == nil {
+							maskYAMLValue(masked, offs, n.Content[i], v)
+							found = true
+						}
+					}
+					continue
+				}
+				walk(v)
+			}
+		}
+	}
+	walk(&root)
+	return masked, found
+}
+
+// isVaultPath reports whether path follows the Ansible convention for
+// vault-encrypted variable files (e.g. group_vars/all/vault.yml).
+func isVaultPath(path string) bool {
+	p := strings.ToLower(filepath.ToSlash(path))
+	base := filepath.Base(p)
+	name := strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(base, ".yml"), ".yaml"), ".json")
+	if name != "vault" && !strings.HasSuffix(name, ".vault") && !strings.HasPrefix(name, "vault_") && !strings.HasSuffix(name, "_vault") {
+		return false
+	}
+	for _, dir := range []string{"group_vars/", "host_vars/", "vars/", "defaults/", "ansible"} {
+		if strings.Contains(p, dir) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package artifacts
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// lineOffsets returns the byte offset at which each line of b starts.
+func lineOffsets(b []byte) []int {
+	offs := []int{0}
+	for i, c := range b {
+		if c == '\n' {
+			offs = append(offs, i+1)
+		}
+	}
+	return offs
+}
+
+// jsonValueOffsets maps the path of every value in a JSON document (using
+// the same "a.b[0].c" notation as extractSensitiveJSON) to the byte offset
+// where the value starts in b.
+func jsonValueOffsets(b []byte) map[string]int {
+	type frame struct {
+		path      string
+		array     bool
+		index     int
+		key       string
+		expectKey bool
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	offs := map[string]int{}
+	var stack []*frame
+	next := func() int {
+		o := int(dec.InputOffset())
+		for o < len(b) && strings.IndexByte(" \t\r\n:,", b[o]) >= 0 {
+			o++
+		}
+		return o
+	}
+	advance := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		if top.array {
+			top.index++
+		} else {
+			top.expectKey = true
+		}
+	}
+	for {
+		start := next()
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		var top *frame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			advance()
+			continue
+		}
+		if top != nil && !top.array && top.expectKey {
+			top.key, _ = tok.(string)
+			top.expectKey = false
+			continue
+		}
+		path := ""
+		if top != nil {
+			if top.array {
+				path = top.path + "[" + strconv.Itoa(top.index) + "]"
+			} else if top.path == "" {
+				path = top.key
+			} else {
+				path = top.path + "." + top.key
+			}
+		}
+		offs[path] = start
+		if d, ok := tok.(json.Delim); ok {
+			stack = append(stack, &frame{path: path, array: d == '[', expectKey: d == '{'})
+			continue
+		}
+		advance()
+	}
+	return offs
+}
+
+// offsetToLineCol converts a byte offset into a 1-based line and column
+// using the line start offsets from lineOffsets.
+func offsetToLineCol(lines []int, off int) (int, int) {
+	lo, hi := 0, len(lines)-1
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if lines[mid] <= off {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo + 1, off - lines[lo] + 1
+}
+
+// jsonEntrySpan returns the byte range of a sensitive object member: from
+// the opening quote of the key whose value starts at memberValue to the end
+// of the string value starting at leaf. For a plain "key": "value" member
+// memberValue and leaf are equal; for {"key": {"value": "..."}} the range
+// spans from the outer key to the nested value.
+func jsonEntrySpan(b []byte, memberValue, leaf int) (int, int) {
+	start := memberValue
+	// Walk back over whitespace and the colon to the key's closing quote.
+	i := memberValue - 1
+	for i >= 0 && strings.IndexByte(" \t\r\n:", b[i]) >= 0 {
+		i--
+	}
+	if i >= 0 && b[i] == '"' {
+		j := i - 1
+		for j >= 0 && !(b[j] == '"' && (j == 0 || b[j-1] != '\\')) {
+			j--
+		}
+		if j >= 0 {
+			start = j
+		}
+	}
+	end := leaf
+	if leaf < len(b) && b[leaf] == '"' {
+		end = leaf + 1
+		for end < len(b) && b[end] != '"' {
+			if b[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end < len(b) {
+			end++
+		}
+	}
+	if end > len(b) {
+		end = len(b)
+	}
+	return start, end
+}
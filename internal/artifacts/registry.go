@@ -46,7 +46,7 @@ func ScanRegistryImage(imageRef string, limits Limits, emit func(path string, da
 		// Check global/artifact limits before starting a layer
 		if r := limitsExceededReason(limits, decompressed, entries, 0, deadline); r != "" {
 			if stats != nil {
-				stats.add(r)
+				stats.add(imageRef, r)
 			}
 			return nil // Abort scanning this image
 		}
@@ -0,0 +1,782 @@
+package artifacts
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/varalys/redactyl/internal/types"
+)
+
+// Detector IDs for structural findings raised on Terraform sources.
+const (
+	DetectorTerraformLiteral   = "terraform-literal-credential"
+	DetectorTerraformPlanValue = "terraform-plan-sensitive-value"
+)
+
+// IsTerraformPath reports whether path is Terraform HCL source (.tf) or a
+// variable definitions file (.tfvars).
+func IsTerraformPath(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasSuffix(lower, ".tf") || strings.HasSuffix(lower, ".tfvars")
+}
+
+// looksLikeTerraformPlan sniffs for the top-level keys written by
+// `terraform show -json` for a saved plan.
+func looksLikeTerraformPlan(b []byte) bool {
+	return bytes.Contains(b, []byte(`"format_version"`)) &&
+		(bytes.Contains(b, []byte(`"resource_changes"`)) || bytes.Contains(b, []byte(`"planned_values"`)))
+}
+
+// InspectTerraform returns structural findings for literal credentials in
+// Terraform HCL (variable defaults, provider and backend blocks, resource and
+// module arguments, tfvars) and for sensitive values present in plan JSON.
+// Findings carry the Terraform address in the "iac_address" metadata key,
+// prefixed with module.<name>. as resolved by modules (which may be nil).
+func InspectTerraform(path string, data []byte, modules *TerraformModules) []types.Finding {
+	if IsTerraformPath(path) {
+		return inspectTerraformHCL(path, data, modules.Prefix(path))
+	}
+	if strings.HasSuffix(strings.ToLower(path), ".json") && looksLikeTerraformPlan(data) {
+		return inspectTerraformPlan(path, data)
+	}
+	return nil
+}
+
+// TerraformModules resolves the module.<name>. address prefix of Terraform
+// files from the module blocks that call their directory with a local
+// source (e.g. module "db" { source = "./modules/postgres" }).
+type TerraformModules struct {
+	calls map[string][]moduleCall // called directory -> its callers
+}
+
+type moduleCall struct {
+	dir  string // directory of the calling module
+	name string // label of the module block
+}
+
+// LoadTerraformModules reads the module blocks of the .tf files under root.
+// Paths are slash-separated and relative to root.
+func LoadTerraformModules(root string) *TerraformModules {
+	m := &TerraformModules{calls: map[string][]moduleCall{}}
+	_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != root && (d.Name() == ".git" || d.Name() == ".terraform" || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(strings.ToLower(d.Name()), ".tf") {
+			return nil
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		dir := path.Dir(filepath.ToSlash(rel))
+		for _, a := range parseHCL(rel, b, "").attrs {
+			if a.blockType != "module" || a.blockLabel == "" || len(a.path) != 1 || a.path[0] != "source" || !a.literal {
+				continue
+			}
+			if !strings.HasPrefix(a.value, "./") && !strings.HasPrefix(a.value, "../") {
+				continue // registry and remote sources are not in the tree
+			}
+			target := path.Join(dir, a.value)
+			m.calls[target] = append(m.calls[target], moduleCall{dir: dir, name: a.blockLabel})
+		}
+		return nil
+	})
+	return m
+}
+
+// Prefix returns the module.<name>. prefix (nested for modules called by
+// modules) of the Terraform file at path. It returns "" for root modules and
+// for modules called from more than one place, which have several addresses.
+func (m *TerraformModules) Prefix(file string) string {
+	if m == nil {
+		return ""
+	}
+	return m.dirPrefix(path.Dir(filepath.ToSlash(file)), map[string]bool{})
+}
+
+func (m *TerraformModules) dirPrefix(dir string, visiting map[string]bool) string {
+	calls := m.calls[dir]
+	if len(calls) != 1 || visiting[dir] {
+		return ""
+	}
+	visiting[dir] = true
+	return m.dirPrefix(calls[0].dir, visiting) + "module." + calls[0].name + "."
+}
+
+// TerraformAddresses is parsed HCL source for looking up the addresses of
+// several lines without parsing the file again for each.
+type TerraformAddresses struct {
+	doc *hclDoc
+}
+
+// ParseTerraformAddresses parses HCL source for AddressAt, prefixing
+// addresses as resolved by modules (which may be nil). It returns nil, for
+// which AddressAt always returns "", when path is not Terraform source.
+func ParseTerraformAddresses(path string, data []byte, modules *TerraformModules) *TerraformAddresses {
+	if !IsTerraformPath(path) {
+		return nil
+	}
+	return &TerraformAddresses{doc: parseHCL(path, data, modules.Prefix(path))}
+}
+
+// AddressAt returns the Terraform address of the attribute (or, failing
+// that, the innermost block) covering line, e.g.
+// "module.db.aws_db_instance.main.password", or "" when nothing covers it.
+func (t *TerraformAddresses) AddressAt(line int) string {
+	if t == nil || line <= 0 {
+		return ""
+	}
+	doc := t.doc
+	best, span := "", -1
+	for _, a := range doc.attrs {
+		if line >= a.line && line <= a.endLine && (span < 0 || a.endLine-a.line < span) {
+			best, span = a.address, a.endLine-a.line
+		}
+	}
+	if best != "" {
+		return best
+	}
+	for _, b := range doc.blocks {
+		if line >= b.line && line <= b.endLine && (span < 0 || b.endLine-b.line < span) {
+			best, span = b.address, b.endLine-b.line
+		}
+	}
+	return best
+}
+
+// iacReferenceSuffixes mark argument names that hold identifiers or settings
+// about a secret rather than the secret itself. They are matched against the
+// lower-cased name with separators removed, so secret_name, SecretName and
+// secretName are all treated alike.
+var iacReferenceSuffixes = []string{"name", "id", "ids", "arn", "path", "file", "version", "type", "length", "ttl", "policy", "mode", "enabled", "rotation", "url", "uri", "ref"}
+
+// iacCredentialKey reports whether an IaC argument or property name is likely
+// to hold a credential.
+func iacCredentialKey(k string) bool {
+	l := strings.ToLower(k)
+	norm := strings.NewReplacer("_", "", "-", "").Replace(l)
+	for _, s := range iacReferenceSuffixes {
+		if strings.HasSuffix(norm, s) {
+			return false
+		}
+	}
+	return keyLooksSensitive(k) || norm == "accesskey" || norm == "secretkey" || norm == "clientcertificate" || norm == "connectionstring"
+}
+
+// iacFinding builds a structural finding for a literal credential in an IaC
+// source, recording its address in the "iac_address" metadata key.
+func iacFinding(path string, line, col int, detector, kind, address, value, desc string, confidence float64) types.Finding {
+	sev := types.SevMed
+	if confidence >= 0.9 {
+		sev = types.SevHigh
+	}
+	return types.Finding{
+		Path:       path,
+		Line:       line,
+		Column:     col,
+		Match:      value,
+		Secret:     value,
+		Detector:   detector,
+		Severity:   sev,
+		Confidence: confidence,
+		Context:    desc + " (" + address + ")",
+		Metadata:   map[string]string{"iac_address": address, "iac_kind": kind},
+	}
+}
+
+func inspectTerraformHCL(path string, data []byte, prefix string) []types.Finding {
+	doc := parseHCL(path, data, prefix)
+	var findings []types.Finding
+	for _, a := range doc.attrs {
+		if !a.literal || len(a.value) < 4 || len(a.path) == 0 {
+			continue
+		}
+		key := a.path[len(a.path)-1]
+		confidence := 0.8
+		kind := a.blockType
+		switch a.blockType {
+		case "output":
+			continue
+		case "variable":
+			if a.path[0] != "default" {
+				continue
+			}
+			sensitive := doc.sensitive[a.blockAddress]
+			if !sensitive && !iacCredentialKey(a.blockLabel) && !iacCredentialKey(key) {
+				continue
+			}
+			if sensitive {
+				confidence = 0.9
+			}
+			kind = "variable default"
+		default:
+			if !iacCredentialKey(key) {
+				continue
+			}
+			if kind == "" {
+				kind = "tfvars"
+			}
+		}
+		findings = append(findings, iacFinding(path, a.valueLine, a.valueCol, DetectorTerraformLiteral, "terraform", a.address, a.value, "literal credential in "+kind, confidence))
+	}
+	return findings
+}
+
+func inspectTerraformPlan(path string, data []byte) []types.Finding {
+	var plan struct {
+		Variables       map[string]struct{ Value any } `json:"variables"`
+		ResourceChanges []struct {
+			Address string `json:"address"`
+			Change  struct {
+				After          any `json:"after"`
+				AfterSensitive any `json:"after_sensitive"`
+			} `json:"change"`
+		} `json:"resource_changes"`
+		OutputChanges map[string]struct {
+			After          any `json:"after"`
+			AfterSensitive any `json:"after_sensitive"`
+		} `json:"output_changes"`
+		Configuration struct {
+			RootModule struct {
+				Variables map[string]struct {
+					Sensitive bool `json:"sensitive"`
+				} `json:"variables"`
+			} `json:"root_module"`
+		} `json:"configuration"`
+	}
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil
+	}
+	positions := jsonValueOffsets(data)
+	lines := lineOffsets(data)
+	var findings []types.Finding
+	add := func(address, jsonPath, value string) {
+		line, col := 1, 1
+		if off, ok := positions[jsonPath]; ok {
+			line, col = offsetToLineCol(lines, off)
+			col++ // skip the opening quote
+		}
+		findings = append(findings, iacFinding(path, line, col, DetectorTerraformPlanValue, "terraform-plan", address, value, "sensitive value stored in Terraform plan", 0.9))
+	}
+	for i, rc := range plan.ResourceChanges {
+		base := "resource_changes[" + strconv.Itoa(i) + "].change.after"
+		walkPlanSensitive(rc.Change.After, rc.Change.AfterSensitive, false, rc.Address, base, add)
+	}
+	for name, oc := range plan.OutputChanges {
+		walkPlanSensitive(oc.After, oc.AfterSensitive, false, "output."+name, "output_changes."+name+".after", add)
+	}
+	for name, v := range plan.Variables {
+		if !plan.Configuration.RootModule.Variables[name].Sensitive {
+			continue
+		}
+		walkPlanSensitive(v.Value, true, true, "var."+name, "variables."+name+".value", add)
+	}
+	return findings
+}
+
+// walkPlanSensitive walks a planned value alongside its sensitivity mask
+// (true, or a structure of the same shape) and reports non-empty strings
+// that Terraform marks as sensitive.
+func walkPlanSensitive(value, mask any, inherited bool, address, jsonPath string, add func(address, jsonPath, value string)) {
+	sensitive := inherited
+	if b, ok := mask.(bool); ok && b {
+		sensitive = true
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		m, _ := mask.(map[string]any)
+		for k, child := range v {
+			walkPlanSensitive(child, m[k], sensitive, address+"."+k, jsonPath+"."+k, add)
+		}
+	case []any:
+		m, _ := mask.([]any)
+		for i, child := range v {
+			var cm any
+			if i < len(m) {
+				cm = m[i]
+			}
+			idx := "[" + strconv.Itoa(i) + "]"
+			walkPlanSensitive(child, cm, sensitive, address+idx, jsonPath+idx, add)
+		}
+	case string:
+		if sensitive && v != "" {
+			add(address, jsonPath, v)
+		}
+	}
+}
+
+// --- HCL ---
+
+type hclTokKind int
+
+const (
+	hclIdent hclTokKind = iota
+	hclString
+	hclHeredoc
+	hclNumber
+	hclLBrace
+	hclRBrace
+	hclLBrack
+	hclRBrack
+	hclLParen
+	hclRParen
+	hclEquals
+	hclColon
+	hclComma
+	hclNewline
+	hclOther
+)
+
+type hclToken struct {
+	kind    hclTokKind
+	text    string // identifier text or decoded string content
+	literal bool   // strings only: no ${...} or %{...} sequences
+	line    int
+	col     int
+	endLine int
+}
+
+// lexHCL is a small tokenizer covering the subset of HCL syntax needed to
+// find blocks and attribute assignments. It is deliberately forgiving:
+// anything it does not understand becomes an hclOther token.
+func lexHCL(b []byte) []hclToken {
+	var toks []hclToken
+	line, lineStart := 1, 0
+	i := 0
+	consume := func(to int) {
+		for j := i; j < to && j < len(b); j++ {
+			if b[j] == '\n' {
+				line++
+				lineStart = j + 1
+			}
+		}
+		i = to
+	}
+	emit := func(kind hclTokKind, text string, width int) {
+		toks = append(toks, hclToken{kind: kind, text: text, line: line, col: i - lineStart + 1, endLine: line})
+		consume(i + width)
+	}
+	for i < len(b) {
+		c := b[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '\n':
+			emit(hclNewline, "", 1)
+		case c == '#' || (c == '/' && i+1 < len(b) && b[i+1] == '/'):
+			for i < len(b) && b[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(b) && b[i+1] == '*':
+			end := bytes.Index(b[i+2:], []byte("*/"))
+			if end < 0 {
+				consume(len(b))
+			} else {
+				consume(i + 2 + end + 2)
+			}
+		case c == '"':
+			startLine, startCol := line, i-lineStart+1
+			j, depth, literal := i+1, 0, true
+			for j < len(b) {
+				ch := b[j]
+				if ch == '\\' {
+					j += 2
+					continue
+				}
+				if depth == 0 && ch == '"' {
+					break
+				}
+				if depth == 0 && ch == '\n' {
+					break // unterminated
+				}
+				if (ch == '$' || ch == '%') && j+1 < len(b) && b[j+1] == '{' {
+					literal = false
+					depth++
+					j += 2
+					continue
+				}
+				if depth > 0 && ch == '{' {
+					depth++
+				} else if depth > 0 && ch == '}' {
+					depth--
+				}
+				j++
+			}
+			if j > len(b) {
+				j = len(b)
+			}
+			raw := string(b[i+1 : j])
+			text := raw
+			if literal {
+				if s, err := strconv.Unquote(`"` + raw + `"`); err == nil {
+					text = s
+				}
+			}
+			end := j
+			if end < len(b) && b[end] == '"' {
+				end++
+			}
+			consume(end)
+			toks = append(toks, hclToken{kind: hclString, text: text, literal: literal, line: startLine, col: startCol + 1, endLine: line})
+		case c == '<' && i+2 < len(b) && b[i+1] == '<' && (isHCLIdentStart(b[i+2]) || b[i+2] == '-'):
+			j := i + 2
+			if b[j] == '-' {
+				j++
+			}
+			k := j
+			for k < len(b) && isHCLIdentPart(b[k]) {
+				k++
+			}
+			marker := string(b[j:k])
+			nl := bytes.IndexByte(b[k:], '\n')
+			if marker == "" || nl < 0 {
+				emit(hclOther, "<", 1)
+				continue
+			}
+			bodyStart := k + nl + 1
+			startLine := line + 1
+			end, bodyEnd := len(b), len(b)
+			for p := bodyStart; p < len(b); {
+				q := bytes.IndexByte(b[p:], '\n')
+				lineEnd := len(b)
+				if q >= 0 {
+					lineEnd = p + q
+				}
+				if strings.TrimSpace(string(b[p:lineEnd])) == marker {
+					bodyEnd, end = p, lineEnd
+					break
+				}
+				if q < 0 {
+					break
+				}
+				p = lineEnd + 1
+			}
+			body := string(b[bodyStart:bodyEnd])
+			literal := !strings.Contains(body, "${") && !strings.Contains(body, "%{")
+			consume(end)
+			toks = append(toks, hclToken{kind: hclHeredoc, text: strings.TrimSuffix(body, "\n"), literal: literal, line: startLine, col: 1, endLine: line})
+		case c == '{':
+			emit(hclLBrace, "{", 1)
+		case c == '}':
+			emit(hclRBrace, "}", 1)
+		case c == '[':
+			emit(hclLBrack, "[", 1)
+		case c == ']':
+			emit(hclRBrack, "]", 1)
+		case c == '(':
+			emit(hclLParen, "(", 1)
+		case c == ')':
+			emit(hclRParen, ")", 1)
+		case c == ',':
+			emit(hclComma, ",", 1)
+		case c == ':':
+			emit(hclColon, ":", 1)
+		case c == '=':
+			if i+1 < len(b) && (b[i+1] == '=' || b[i+1] == '>') {
+				emit(hclOther, string(b[i:i+2]), 2)
+			} else {
+				emit(hclEquals, "=", 1)
+			}
+		case (c == '!' || c == '<' || c == '>') && i+1 < len(b) && b[i+1] == '=':
+			emit(hclOther, string(b[i:i+2]), 2)
+		case isHCLIdentStart(c):
+			j := i + 1
+			for j < len(b) && isHCLIdentPart(b[j]) {
+				j++
+			}
+			emit(hclIdent, string(b[i:j]), j-i)
+		case c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(b) && (b[j] >= '0' && b[j] <= '9' || b[j] == '.' || b[j] == 'e' || b[j] == 'E') {
+				j++
+			}
+			emit(hclNumber, string(b[i:j]), j-i)
+		default:
+			emit(hclOther, string(c), 1)
+		}
+	}
+	return toks
+}
+
+func isHCLIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isHCLIdentPart(c byte) bool {
+	return isHCLIdentStart(c) || c == '-' || (c >= '0' && c <= '9')
+}
+
+// hclAttr is an attribute assignment (or object constructor key) found while
+// parsing. path is relative to the enclosing top-level block and ends with
+// the attribute name.
+type hclAttr struct {
+	blockType    string
+	blockLabel   string
+	blockAddress string
+	path         []string
+	address      string
+	value        string
+	literal      bool
+	line         int
+	endLine      int
+	valueLine    int
+	valueCol     int
+}
+
+type hclBlock struct {
+	address string
+	line    int
+	endLine int
+}
+
+type hclDoc struct {
+	attrs     []hclAttr
+	blocks    []hclBlock
+	sensitive map[string]bool // top-level block addresses declared with sensitive = true
+}
+
+type hclParser struct {
+	toks   []hclToken
+	i      int
+	prefix string
+	doc    *hclDoc
+}
+
+// parseHCL extracts blocks and attributes from Terraform source. Addresses
+// are prefixed with prefix, the module.<name>. path of the module the file
+// belongs to (see TerraformModules); tfvars set root module variables and
+// are never prefixed.
+func parseHCL(path string, data []byte, prefix string) *hclDoc {
+	p := &hclParser{
+		toks:   lexHCL(data),
+		prefix: prefix,
+		doc:    &hclDoc{sensitive: map[string]bool{}},
+	}
+	top := hclScope{}
+	if strings.HasSuffix(strings.ToLower(path), ".tfvars") {
+		top = hclScope{address: "var", varsFile: true}
+		p.prefix = ""
+	}
+	p.parseBody(top, false)
+	return p.doc
+}
+
+// hclScope describes where the parser currently is: inside which top-level
+// block and below which nested block types / object keys.
+type hclScope struct {
+	blockType string
+	label     string
+	address   string // address of the top-level block
+	path      []string
+	varsFile  bool
+}
+
+func (s hclScope) attrAddress(path []string) string {
+	switch {
+	case s.varsFile:
+		return "var." + strings.Join(path, ".")
+	case s.blockType == "variable" && len(path) > 0 && path[0] == "default":
+		path = path[1:]
+	case s.blockType == "output" && len(path) > 0 && path[0] == "value":
+		path = path[1:]
+	case s.blockType == "locals":
+		return "local." + strings.Join(path, ".")
+	}
+	if len(path) == 0 {
+		return s.address
+	}
+	return s.address + "." + strings.Join(path, ".")
+}
+
+func terraformBlockAddress(typ string, labels []string) string {
+	switch {
+	case typ == "resource" && len(labels) >= 2:
+		return labels[0] + "." + labels[1]
+	case typ == "data" && len(labels) >= 2:
+		return "data." + labels[0] + "." + labels[1]
+	case typ == "variable" && len(labels) >= 1:
+		return "var." + labels[0]
+	case typ == "locals":
+		return "local"
+	}
+	return strings.Join(append([]string{typ}, labels...), ".")
+}
+
+func (p *hclParser) peek(off int) *hclToken {
+	if p.i+off < len(p.toks) {
+		return &p.toks[p.i+off]
+	}
+	return nil
+}
+
+// parseBody parses block contents until the matching closing brace (when
+// inBlock) or end of input.
+func (p *hclParser) parseBody(scope hclScope, inBlock bool) {
+	for p.i < len(p.toks) {
+		t := p.toks[p.i]
+		switch t.kind {
+		case hclRBrace:
+			p.i++
+			if inBlock {
+				return
+			}
+			continue
+		case hclIdent:
+		default:
+			p.i++
+			continue
+		}
+		if n := p.peek(1); n != nil && n.kind == hclEquals {
+			p.i += 2
+			p.parseExpr(scope, append(append([]string(nil), scope.path...), t.text), t.line)
+			continue
+		}
+		j := p.i + 1
+		var labels []string
+		for j < len(p.toks) && (p.toks[j].kind == hclString || p.toks[j].kind == hclIdent) {
+			labels = append(labels, p.toks[j].text)
+			j++
+		}
+		if j >= len(p.toks) || p.toks[j].kind != hclLBrace {
+			p.i++
+			continue
+		}
+		p.i = j + 1
+		child := scope
+		if scope.blockType == "" && !scope.varsFile {
+			child = hclScope{blockType: t.text, address: terraformBlockAddress(t.text, labels)}
+			if len(labels) > 0 {
+				child.label = labels[0]
+			}
+		} else {
+			child.path = append(append(append([]string(nil), scope.path...), t.text), labels...)
+		}
+		p.parseBody(child, true)
+		end := t.line
+		if p.i > 0 && p.i <= len(p.toks) {
+			end = p.toks[p.i-1].endLine
+		}
+		addr := child.address
+		if len(child.path) > 0 {
+			addr += "." + strings.Join(child.path, ".")
+		}
+		p.doc.blocks = append(p.doc.blocks, hclBlock{address: p.prefix + addr, line: t.line, endLine: end})
+	}
+}
+
+// parseExpr consumes an attribute value. Pure string literals are recorded
+// with their value; object constructors are descended into so nested keys
+// (e.g. tags = { password = "..." }) are recorded too.
+func (p *hclParser) parseExpr(scope hclScope, path []string, nameLine int) {
+	attr := hclAttr{
+		blockType:    scope.blockType,
+		blockLabel:   scope.label,
+		blockAddress: scope.address,
+		path:         path,
+		address:      p.prefix + scope.attrAddress(path),
+		line:         nameLine,
+		endLine:      nameLine,
+	}
+	if p.i >= len(p.toks) {
+		p.doc.attrs = append(p.doc.attrs, attr)
+		return
+	}
+	first := p.toks[p.i]
+	switch {
+	case (first.kind == hclString || first.kind == hclHeredoc) && p.exprEndsAt(p.i+1):
+		attr.value, attr.literal = first.text, first.literal
+		attr.valueLine, attr.valueCol = first.line, first.col
+		attr.endLine = first.endLine
+		p.i++
+	case first.kind == hclIdent && p.exprEndsAt(p.i+1):
+		if len(path) == 1 && path[0] == "sensitive" && first.text == "true" && (scope.blockType == "variable" || scope.blockType == "output") {
+			p.doc.sensitive[scope.address] = true
+		}
+		p.i++
+	case first.kind == hclLBrace:
+		p.i++
+		p.parseObject(scope, path)
+		if p.i > 0 {
+			attr.endLine = p.toks[p.i-1].endLine
+		}
+	default:
+		p.skipExpr()
+		if p.i > 0 {
+			attr.endLine = p.toks[p.i-1].endLine
+		}
+	}
+	p.doc.attrs = append(p.doc.attrs, attr)
+}
+
+// exprEndsAt reports whether the expression ends before token index i.
+func (p *hclParser) exprEndsAt(i int) bool {
+	if i >= len(p.toks) {
+		return true
+	}
+	switch p.toks[i].kind {
+	case hclNewline, hclComma, hclRBrace, hclRBrack, hclRParen:
+		return true
+	}
+	return false
+}
+
+func (p *hclParser) parseObject(scope hclScope, path []string) {
+	for p.i < len(p.toks) {
+		t := p.toks[p.i]
+		switch t.kind {
+		case hclRBrace:
+			p.i++
+			return
+		case hclIdent, hclString:
+			if n := p.peek(1); n != nil && (n.kind == hclEquals || n.kind == hclColon) {
+				p.i += 2
+				p.parseExpr(scope, append(append([]string(nil), path...), t.text), t.line)
+				continue
+			}
+		}
+		if t.kind == hclNewline || t.kind == hclComma {
+			p.i++
+			continue
+		}
+		p.skipExpr()
+		if p.i < len(p.toks) && p.toks[p.i].kind != hclRBrace {
+			p.i++
+		}
+	}
+}
+
+// skipExpr advances past an expression, stopping at a newline, comma or
+// closing bracket at nesting depth zero.
+func (p *hclParser) skipExpr() {
+	depth := 0
+	for p.i < len(p.toks) {
+		switch p.toks[p.i].kind {
+		case hclLBrace, hclLBrack, hclLParen:
+			depth++
+		case hclRBrace, hclRBrack, hclRParen:
+			if depth == 0 {
+				return
+			}
+			depth--
+		case hclNewline, hclComma:
+			if depth == 0 {
+				return
+			}
+		}
+		p.i++
+	}
+}
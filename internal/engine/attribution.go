@@ -0,0 +1,280 @@
+package engine
+
+import (
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/varalys/redactyl/internal/git"
+	"github.com/varalys/redactyl/internal/types"
+)
+
+// Values of the "history_status" metadata key on history and diff findings.
+const (
+	// HistoryPresent means the secret is still in the file at HEAD.
+	HistoryPresent = "present"
+	// HistoryRemoved means the secret was introduced on HEAD's history and
+	// removed later; it survives only in old commits.
+	HistoryRemoved = "removed"
+	// HistoryUnmerged means the introducing commit is only reachable from
+	// branches or tags that are not merged into HEAD.
+	HistoryUnmerged = "unmerged"
+	// HistoryUncommitted means the secret is in the working tree but not in
+	// any commit yet.
+	HistoryUncommitted = "uncommitted"
+	// HistoryStash means the secret is only in a stash entry (see the
+	// "stash" metadata key for its stash@{n} name).
+	HistoryStash = git.ReachStash
+	// HistoryReflog means the secret is in a commit that only a reflog
+	// references, e.g. after `reset --hard`.
+	HistoryReflog = git.ReachReflog
+	// HistoryUnreachable means the secret is in an object nothing
+	// references; it remains until `git gc` prunes it.
+	HistoryUnreachable = git.ReachUnreachable
+)
+
+// attributeHistory keeps, for each secret in a path, only the finding from
+// the commit that introduced it and records that commit's author, date and
+// subject together with the secret's history status.
+func attributeHistory(cfg Config, findings []types.Finding) []types.Finding {
+	if len(findings) == 0 {
+		return findings
+	}
+	commits := findingCommits(findings)
+	order, _ := git.TopoOrder(cfg.Root, commits)
+	findings = keepIntroductions(findings, order)
+	infos, _ := git.CommitInfos(cfg.Root, commits)
+	annotateCommits(cfg, findings, infos)
+	return findings
+}
+
+// attributeDiff finds the commit on the base..HEAD range that introduced
+// each diff finding and records its details and history status. The patches
+// of the range are read once, and secrets are matched against them in
+// process rather than passed to git, where they would show in its arguments.
+func attributeDiff(cfg Config, findings []types.Finding) []types.Finding {
+	if len(findings) == 0 {
+		return findings
+	}
+	var paths []string
+	seen := map[string]bool{}
+	for _, f := range findings {
+		if !seen[f.Path] {
+			seen[f.Path] = true
+			paths = append(paths, f.Path)
+		}
+	}
+	patches, _ := git.RangeAddedLines(cfg.Root, cfg.BaseBranch+"..HEAD", paths)
+	introduced := map[string]string{}
+	for i := range findings {
+		f := &findings[i]
+		key := f.Path + "\x00" + findingSecret(*f)
+		commit, ok := introduced[key]
+		if !ok {
+			commit = introducingCommit(patches, f.Path, findingSecret(*f))
+			introduced[key] = commit
+		}
+		if commit == "" {
+			continue
+		}
+		if f.Metadata == nil {
+			f.Metadata = map[string]string{}
+		}
+		f.Metadata["commit"] = commit
+	}
+	infos, _ := git.CommitInfos(cfg.Root, findingCommits(findings))
+	annotateCommits(cfg, findings, infos)
+	return findings
+}
+
+// attributeForensics records commit details on forensic findings. Their
+// history status, set while scanning, says how the object is reachable.
+func attributeForensics(cfg Config, findings []types.Finding) []types.Finding {
+	if len(findings) == 0 {
+		return findings
+	}
+	infos, _ := git.CommitInfos(cfg.Root, findingCommits(findings))
+	repo := repositoryURI(cfg.Root)
+	for i := range findings {
+		f := &findings[i]
+		f.Metadata["repository"] = repo
+		if info, ok := infos[f.Metadata["commit"]]; ok {
+			setCommitInfo(f, info)
+		}
+	}
+	return findings
+}
+
+// introducingCommit returns the oldest of patches that adds a line containing
+// secret (its first line, for multi-line secrets) to path, or "" when none
+// does, such as for uncommitted changes.
+func introducingCommit(patches []git.CommitDiff, path, secret string) string {
+	needle, _, _ := strings.Cut(secret, "\n")
+	if needle == "" {
+		return ""
+	}
+	for _, c := range patches {
+		for _, d := range c.Files {
+			if d.Path != path {
+				continue
+			}
+			for _, l := range d.Added {
+				if strings.Contains(l.Text, needle) {
+					return c.Hash
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// findingSecret returns the value to look for in file contents.
+func findingSecret(f types.Finding) string {
+	if f.Secret != "" {
+		return f.Secret
+	}
+	return f.Match
+}
+
+func findingCommits(findings []types.Finding) []string {
+	seen := map[string]bool{}
+	var hashes []string
+	for _, f := range findings {
+		if c := f.Metadata["commit"]; c != "" && !seen[c] {
+			seen[c] = true
+			hashes = append(hashes, c)
+		}
+	}
+	return hashes
+}
+
+// keepIntroductions drops findings for a secret already reported at the same
+// place in a path from an ancestor commit, so a secret that survives many
+// edits to its file is attributed once, to the commit that added it. The
+// place is the secret's occurrence number within the file rather than its
+// line, which moves as lines above it change, so a secret repeated in a file
+// is reported once per copy. order ranks commits ancestors first (see
+// git.TopoOrder); author dates are not used, as rebases and cherry-picks
+// keep them.
+func keepIntroductions(findings []types.Finding, order map[string]int) []types.Finding {
+	keys := occurrenceKeys(findings)
+	rank := func(f types.Finding) int {
+		if r, ok := order[f.Metadata["commit"]]; ok {
+			return r
+		}
+		return len(order)
+	}
+	first := map[string]int{}
+	out := findings[:0]
+	for j, f := range findings {
+		i, ok := first[keys[j]]
+		if !ok {
+			first[keys[j]] = len(out)
+			out = append(out, f)
+			continue
+		}
+		if rank(f) < rank(out[i]) {
+			out[i] = f
+		}
+	}
+	return out
+}
+
+// occurrenceKeys returns, for each finding, its path, detector and secret
+// together with the number of the same secret's earlier occurrences in that
+// file at that commit.
+func occurrenceKeys(findings []types.Finding) []string {
+	groups := map[string][]int{}
+	var order []string
+	for i, f := range findings {
+		g := f.Metadata["commit"] + "\x00" + f.Path + "\x00" + f.Detector + "\x00" + findingSecret(f)
+		if _, ok := groups[g]; !ok {
+			order = append(order, g)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	keys := make([]string, len(findings))
+	for _, g := range order {
+		idx := groups[g]
+		sort.SliceStable(idx, func(a, b int) bool {
+			fa, fb := findings[idx[a]], findings[idx[b]]
+			if fa.Line != fb.Line {
+				return fa.Line < fb.Line
+			}
+			return fa.Column < fb.Column
+		})
+		for n, i := range idx {
+			f := findings[i]
+			keys[i] = f.Path + "\x00" + f.Detector + "\x00" + findingSecret(f) + "\x00" + strconv.Itoa(n)
+		}
+	}
+	return keys
+}
+
+// annotateCommits records commit details, the repository URI and the
+// history status on each finding.
+func annotateCommits(cfg Config, findings []types.Finding, infos map[string]git.CommitInfo) {
+	head, _ := git.ReachableFrom(cfg.Root, "HEAD")
+	var paths []string
+	seen := map[string]bool{}
+	for _, f := range findings {
+		if c := f.Metadata["commit"]; c != "" && head[c] && !seen[f.Path] {
+			seen[f.Path] = true
+			paths = append(paths, f.Path)
+		}
+	}
+	atHead, _ := git.ReadFilesAt(cfg.Root, "HEAD", paths)
+	repo := repositoryURI(cfg.Root)
+
+	for i := range findings {
+		f := &findings[i]
+		if f.Metadata == nil {
+			f.Metadata = map[string]string{}
+		}
+		f.Metadata["repository"] = repo
+		commit := f.Metadata["commit"]
+		if info, ok := infos[commit]; ok {
+			setCommitInfo(f, info)
+		}
+		switch {
+		case commit == "":
+			f.Metadata["history_status"] = HistoryUncommitted
+		case !head[commit]:
+			f.Metadata["history_status"] = HistoryUnmerged
+		case git.IsMessagePath(f.Path):
+			// messages cannot be edited away, only rewritten
+			f.Metadata["history_status"] = HistoryPresent
+		case bytes.Contains(atHead[f.Path], []byte(findingSecret(*f))):
+			f.Metadata["history_status"] = HistoryPresent
+		default:
+			f.Metadata["history_status"] = HistoryRemoved
+		}
+	}
+}
+
+func setCommitInfo(f *types.Finding, info git.CommitInfo) {
+	f.Metadata["author"] = info.Author
+	f.Metadata["author_email"] = info.AuthorEmail
+	f.Metadata["date"] = info.AuthorDate.Format(time.RFC3339)
+	f.Metadata["commit_subject"] = info.Subject
+}
+
+// repositoryURI identifies the scanned repository: the origin remote when
+// one is configured, else a file URI for the local checkout.
+func repositoryURI(root string) string {
+	if u := git.RemoteURL(root); u != "" {
+		return u
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return ""
+	}
+	abs = filepath.ToSlash(abs)
+	if !strings.HasPrefix(abs, "/") {
+		abs = "/" + abs
+	}
+	return "file://" + abs
+}
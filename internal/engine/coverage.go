@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/varalys/redactyl/internal/artifacts"
+	"github.com/varalys/redactyl/internal/git"
+)
+
+// Reasons a path was not scanned, as recorded in Coverage.Skipped. Artifact
+// guardrail aborts use "artifact-" followed by the limit: artifact-bytes,
+// artifact-entries, artifact-depth or artifact-time.
+const (
+	SkipDefaultExclude = "default-exclude" // built-in exclude list (--default-excludes)
+	SkipGlob           = "glob"            // --include / --exclude
+	SkipIgnoreFile     = "redactylignore"  // matched .redactylignore
+	SkipIgnoreMarker   = "ignore-marker"   // file contains redactyl:ignore-file
+	SkipMaxBytes       = "max-bytes"       // larger than --max-bytes
+	SkipBinary         = "binary"          // NUL bytes near the start
+	SkipMIME           = "mime"            // image, media or archive by extension or magic bytes
+	SkipUnreadable     = "unreadable"      // could not be read
+	SkipCached         = "cached"          // unchanged since the last scan (incremental cache)
+)
+
+// Artifact types used as keys of Coverage.ScannedBytes.
+const (
+	KindFile      = "file"      // working tree and staged files
+	KindHistory   = "history"   // commits, refs, forensics and commit messages
+	KindArchive   = "archive"   // entries of zip/tar/gz archives
+	KindContainer = "container" // files in container image tarballs
+	KindIaC       = "iac"       // IaC hotspots and extracted fragments
+	KindHelm      = "helm"      // Helm charts
+	KindK8s       = "k8s"       // Kubernetes manifests
+	KindRegistry  = "registry"  // files in remote registry images
+)
+
+// SkippedPath is a file, directory or artifact that was not (fully) scanned.
+type SkippedPath struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+	Size   int64  `json:"size"` // bytes on disk; for directories, of every file below
+	Dir    bool   `json:"dir,omitempty"`
+	Commit string `json:"commit,omitempty"` // set for history blobs and messages
+}
+
+// Coverage records what a scan skipped and how much it scanned, so a scan
+// can be checked for completeness. Set Config.RecordCoverage to collect it.
+type Coverage struct {
+	Skipped      []SkippedPath    `json:"skipped"`
+	ScannedBytes map[string]int64 `json:"scanned_bytes"` // by artifact type (Kind*)
+	ScannedFiles map[string]int   `json:"scanned_files"` // by artifact type (Kind*)
+}
+
+// CoverageTotal sums the skipped paths for one reason.
+type CoverageTotal struct {
+	Reason string `json:"reason"`
+	Count  int    `json:"count"`
+	Bytes  int64  `json:"bytes"`
+}
+
+func newCoverage() *Coverage {
+	return &Coverage{ScannedBytes: map[string]int64{}, ScannedFiles: map[string]int{}}
+}
+
+// Totals returns the skipped paths summed by reason, sorted by reason.
+func (c *Coverage) Totals() []CoverageTotal {
+	if c == nil {
+		return nil
+	}
+	idx := map[string]int{}
+	var out []CoverageTotal
+	for _, s := range c.Skipped {
+		i, ok := idx[s.Reason]
+		if !ok {
+			i = len(out)
+			idx[s.Reason] = i
+			out = append(out, CoverageTotal{Reason: s.Reason})
+		}
+		out[i].Count++
+		out[i].Bytes += s.Size
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Reason < out[j].Reason })
+	return out
+}
+
+func (c *Coverage) skip(path, reason string, size int64) {
+	c.skipAt(path, "", reason, size)
+}
+
+// skipAt records a skip of path as of commit, for history scans.
+func (c *Coverage) skipAt(path, commit, reason string, size int64) {
+	if c != nil {
+		c.Skipped = append(c.Skipped, SkippedPath{Path: path, Reason: reason, Size: size, Commit: commit})
+	}
+}
+
+// skipDir records a directory that was not descended into, sized by the
+// files below it.
+func (c *Coverage) skipDir(full, rel, reason string) {
+	if c == nil {
+		return
+	}
+	var size int64
+	_ = filepath.WalkDir(full, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	c.Skipped = append(c.Skipped, SkippedPath{Path: filepath.ToSlash(rel) + "/", Reason: reason, Size: size, Dir: true})
+}
+
+func (c *Coverage) scanned(kind string, n int) {
+	if c != nil {
+		c.ScannedBytes[kind] += int64(n)
+		c.ScannedFiles[kind]++
+	}
+}
+
+// addAborts records artifacts whose scan stopped at a guardrail, sized by
+// the artifact on disk when it is one.
+func (c *Coverage) addAborts(root string, aborts []artifacts.Abort) {
+	if c == nil {
+		return
+	}
+	for _, a := range aborts {
+		var size int64
+		outer, _, _ := strings.Cut(a.Path, "::")
+		if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(outer))); err == nil {
+			size = info.Size()
+		}
+		c.skip(a.Path, "artifact-"+a.Reason, size)
+	}
+}
+
+// merge adds a submodule's coverage under its path.
+func (c *Coverage) merge(sub *Coverage, prefix string) {
+	if c == nil || sub == nil {
+		return
+	}
+	for _, s := range sub.Skipped {
+		if !git.IsMessagePath(s.Path) {
+			s.Path = prefix + "/" + s.Path
+		}
+		c.Skipped = append(c.Skipped, s)
+	}
+	for k, v := range sub.ScannedBytes {
+		c.ScannedBytes[k] += v
+	}
+	for k, v := range sub.ScannedFiles {
+		c.ScannedFiles[k] += v
+	}
+}
+
+// modeKind is the artifact type of a job without an explicit one.
+func modeKind(mode string) string {
+	if mode == ModeHistory {
+		return KindHistory
+	}
+	return KindFile
+}
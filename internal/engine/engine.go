@@ -1,12 +1,13 @@
 package engine
 
 import (
-	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	doublestar "github.com/bmatcuk/doublestar/v4"
@@ -29,7 +30,21 @@ type Config struct {
 	MaxBytes         int64
 	ScanStaged       bool
 	HistoryCommits   int
+	AllHistory       bool // scan every commit reachable from any branch or tag
 	BaseBranch       string
+	RevRange         string   // scan commits in a revision range (e.g. v1.0..v2.0)
+	Since            string   // only scan commits more recent than this date
+	Until            string   // only scan commits older than this date
+	Author           string   // only scan commits whose author matches
+	Branches         []string // scan commits on branches matching these patterns
+	Ref              string   // scan the tree of a commit, tag or tree without checking it out
+	GitForensics     bool     // scan stashes, reflog-only commits and unreachable objects
+	Revisions        []string // scan commits reachable from these revisions (e.g. pushed tips)
+	ExcludeExisting  bool     // skip commits reachable from any existing ref (server-side hooks)
+	ExcludeRevisions []string // skip commits reachable from these revisions
+	ExcludeRemote    string   // skip commits on this remote's tracking branches
+	Submodules       bool     // also scan initialised submodules
+	LFS              bool     // scan Git LFS objects from the local store in place of their pointers
 	Threads          int
 	EnableDetectors  string
 	DisableDetectors string
@@ -39,6 +54,15 @@ type Config struct {
 	DefaultExcludes  bool
 	NoCache          bool
 	Progress         func()
+	RecordScanned    bool // list every scanned path in Result.Scanned
+	RecordCoverage   bool // record skipped paths and scanned bytes in Result.Coverage
+
+	// Streaming (optional). OnFinding receives each finding once, as soon as
+	// it is produced; history, forensics and --base findings arrive when
+	// their phase ends, after commit attribution. OnProgress receives an
+	// event per scanned file. Both are called from the scanning goroutine.
+	OnFinding  func(types.Finding)
+	OnProgress func(ProgressEvent)
 
 	// Deep artifact scanning (optional)
 	ScanArchives         bool
@@ -55,12 +79,35 @@ type Config struct {
 
 	// Gitleaks configuration (for scanner integration)
 	GitleaksConfig config.GitleaksConfig
+
+	lfs      *lfsResolver // set by ScanWithStats when LFS is enabled
+	mode     string       // scan mode of the phase in progress, for Result.Scanned
+	coverage *Coverage    // set by ScanWithStats when RecordCoverage is set
+	modules  *tfModules   // set by ScanWithStats, loaded on first use
+}
+
+// tfModules loads the Terraform module tree under Root the first time a
+// finding in HCL source needs an address, so scans without Terraform
+// findings never walk for it.
+type tfModules struct {
+	root string
+	once sync.Once
+	m    *artifacts.TerraformModules
+}
+
+func (t *tfModules) get() *artifacts.TerraformModules {
+	if t == nil {
+		return nil
+	}
+	t.once.Do(func() { t.m = artifacts.LoadTerraformModules(t.root) })
+	return t.m
 }
 
 type pendingScan struct {
 	input    scanner.BatchInput
 	cacheKey string
 	cacheVal string
+	kind     string // artifact type for Coverage.ScannedBytes; defaults to the mode's
 }
 
 func cloneMetadata(meta map[string]string) map[string]string {
@@ -126,6 +173,7 @@ func processChunk(scnr scanner.Scanner, cfg Config, chunk []pendingScan, emit fu
 		if err == nil {
 			findings = filterByConfidence(findings, cfg.MinConfidence)
 			findings = filterByIDs(findings, cfg.EnableDetectors, cfg.DisableDetectors)
+			annotateTerraform(cfg, chunk, findings)
 			emit(findings)
 		} else {
 			cacheOK = false
@@ -134,9 +182,22 @@ func processChunk(scnr scanner.Scanner, cfg Config, chunk []pendingScan, emit fu
 
 	for _, job := range chunk {
 		res.FilesScanned++
+		if cfg.RecordScanned {
+			res.Scanned = append(res.Scanned, ScannedPath{Mode: cfg.mode, Path: job.input.Path})
+		}
+		if cfg.coverage != nil {
+			kind := job.kind
+			if kind == "" {
+				kind = modeKind(cfg.mode)
+			}
+			cfg.coverage.scanned(kind, len(job.input.Data))
+		}
 		if cfg.Progress != nil {
 			cfg.Progress()
 		}
+		if cfg.OnProgress != nil {
+			cfg.OnProgress(ProgressEvent{Mode: cfg.mode, Path: job.input.Path, FilesScanned: res.FilesScanned})
+		}
 		if cacheOK && !cfg.NoCache && !cfg.DryRun && job.cacheKey != "" && job.cacheVal != "" {
 			updated[job.cacheKey] = job.cacheVal
 		}
@@ -144,6 +205,60 @@ func processChunk(scnr scanner.Scanner, cfg Config, chunk []pendingScan, emit fu
 	return nil
 }
 
+// inspectEncrypted masks ciphertext in SOPS, SealedSecret and Ansible Vault
+// files before they reach the detectors and emits structural findings for
+// plaintext values stored in files that claim to be encrypted.
+func inspectEncrypted(cfg Config, path string, data []byte, emit func([]types.Finding)) []byte {
+	masked, findings := artifacts.InspectEncrypted(path, data)
+	emitStructural(cfg, findings, emit)
+	return masked
+}
+
+// emitStructural applies the confidence and detector filters to findings
+// raised by analysers (rather than the detector engine) and emits them.
+func emitStructural(cfg Config, findings []types.Finding, emit func([]types.Finding)) {
+	if len(findings) == 0 || cfg.DryRun {
+		return
+	}
+	findings = filterByConfidence(findings, cfg.MinConfidence)
+	findings = filterByIDs(findings, cfg.EnableDetectors, cfg.DisableDetectors)
+	emit(findings)
+}
+
+// annotateTerraform records the Terraform address (e.g.
+// module.db.aws_db_instance.main.password) on detector findings in HCL
+// sources so reports do not have to rely on a bare line number. Each source
+// is parsed once per chunk, however many findings it has.
+func annotateTerraform(cfg Config, chunk []pendingScan, findings []types.Finding) {
+	var sources map[string][]byte
+	parsed := map[string]*artifacts.TerraformAddresses{}
+	for i := range findings {
+		f := &findings[i]
+		if !artifacts.IsTerraformPath(f.Path) {
+			continue
+		}
+		if sources == nil {
+			sources = make(map[string][]byte, len(chunk))
+			for _, job := range chunk {
+				sources[job.input.Path] = job.input.Data
+			}
+		}
+		doc, ok := parsed[f.Path]
+		if !ok {
+			doc = artifacts.ParseTerraformAddresses(f.Path, sources[f.Path], cfg.modules.get())
+			parsed[f.Path] = doc
+		}
+		addr := doc.AddressAt(f.Line)
+		if addr == "" {
+			continue
+		}
+		if f.Metadata == nil {
+			f.Metadata = map[string]string{}
+		}
+		f.Metadata["iac_address"] = addr
+	}
+}
+
 // DetectorIDs returns the list of available Gitleaks detector IDs.
 // This is a representative list of common Gitleaks rules for UI purposes.
 // The actual detection is performed by Gitleaks with its full rule set.
@@ -167,6 +282,9 @@ type Result struct {
 	Duration       time.Duration
 	ArtifactStats  DeepStats
 	ArtifactErrors []error
+	MissingLFS     []MissingLFSObject // LFS pointers without a local object (LFS only)
+	Scanned        []ScannedPath      // every path scanned, once per mode (RecordScanned only)
+	Coverage       *Coverage          // skipped paths and scanned bytes (RecordCoverage only)
 }
 
 // DeepStats summarizes artifact scanning abort reasons.
@@ -198,16 +316,33 @@ func ScanWithStats(cfg Config) (Result, error) {
 		cfg.Threads = runtime.GOMAXPROCS(0)
 	}
 
-	ign, _ := ignore.Load(filepath.Join(cfg.Root, ".redactylignore"))
+	ign := loadIgnore(cfg)
 	ctx := context.Background()
+	if cfg.RecordCoverage {
+		cfg.coverage = newCoverage()
+		result.Coverage = cfg.coverage
+	}
+	cfg.lfs = newLFSResolver(cfg)
+	cfg.modules = &tfModules{root: cfg.Root}
 
 	var out []types.Finding
 	started := time.Now()
+	streamed := map[string]bool{}
 	emit := func(fs []types.Finding) {
 		out = append(out, fs...)
+		if cfg.OnFinding == nil {
+			return
+		}
+		for _, f := range fs {
+			if k := dedupeKey(f); !streamed[k] {
+				streamed[k] = true
+				cfg.OnFinding(f)
+			}
+		}
 	}
 
-	if cfg.HistoryCommits == 0 && cfg.BaseBranch == "" {
+	cfg.mode = ModeFilesystem
+	if cfg.scansWorkingTree() {
 		if err := scanFilesystem(ctx, cfg, scnr, ign, db, emit, updated, &result); err != nil {
 			return result, err
 		}
@@ -219,25 +354,65 @@ func ScanWithStats(cfg Config) (Result, error) {
 		}
 	}
 
-	if cfg.HistoryCommits > 0 {
-		if err := scanHistory(cfg, scnr, ign, emit, updated, &result); err != nil {
+	cfg.mode = ModeHistory
+	if _, ok := cfg.revSelection(); ok || cfg.HistoryCommits > 0 {
+		var hist []types.Finding
+		collect := func(fs []types.Finding) { hist = append(hist, fs...) }
+		scan := scanHistory
+		if ok {
+			scan = scanRevisions
+		}
+		if err := scan(cfg, scnr, ign, collect, updated, &result); err != nil {
+			return result, err
+		}
+		if err := scanMessages(cfg, scnr, ign, collect, updated); err != nil {
+			return result, err
+		}
+		emit(attributeHistory(cfg, hist))
+	}
+
+	if cfg.GitForensics {
+		var hidden []types.Finding
+		collect := func(fs []types.Finding) { hidden = append(hidden, fs...) }
+		if err := scanForensics(cfg, scnr, ign, collect, updated, &result); err != nil {
+			return result, err
+		}
+		emit(attributeForensics(cfg, hidden))
+	}
+
+	if cfg.Ref != "" {
+		if err := scanRef(cfg, scnr, ign, emit, updated, &result); err != nil {
 			return result, err
 		}
 	}
 
 	if cfg.BaseBranch != "" {
-		if err := scanDiff(cfg, scnr, ign, emit, updated, &result); err != nil {
+		var diff []types.Finding
+		collect := func(fs []types.Finding) { diff = append(diff, fs...) }
+		if err := scanDiff(cfg, scnr, ign, collect, updated, &result); err != nil {
+			return result, err
+		}
+		emit(attributeDiff(cfg, diff))
+	}
+
+	if cfg.scansSubmodules() {
+		if err := scanSubmodules(cfg, ign, emit, &result); err != nil {
 			return result, err
 		}
 	}
 
+	cfg.mode = ModeArtifacts
 	if cfg.ScanArchives || cfg.ScanContainers || cfg.ScanIaC || cfg.ScanHelm || cfg.ScanK8s {
 		if err := scanArtifacts(cfg, scnr, emit, updated, &result); err != nil {
 			return result, err
 		}
 	}
 
-	result.Findings = out
+	if cfg.lfs != nil {
+		result.MissingLFS = append(result.MissingLFS, cfg.lfs.missing...)
+	}
+	result.Findings = dedupeFindings(out)
+	result.Scanned = dedupeScanned(result.Scanned)
 	result.Duration = time.Since(started)
 	if !cfg.NoCache && len(updated) > 0 {
 		if db.Entries == nil {
@@ -260,12 +435,14 @@ func scanFilesystem(ctx context.Context, cfg Config, scnr scanner.Scanner, ign i
 		if walkErr != nil {
 			return
 		}
+		data, oid := cfg.lfs.resolve(p, "", data)
 		h := fastHash(data)
 		if !cfg.NoCache && db.Entries != nil && db.Entries[p] == h {
+			cfg.coverage.skip(p, SkipCached, int64(len(data)))
 			return
 		}
 		queue = append(queue, pendingScan{
-			input:    makeBatchInput(p, data, nil),
+			input:    makeBatchInput(p, inspectEncrypted(cfg, p, data, emit), lfsContext(nil, oid)),
 			cacheKey: p,
 			cacheVal: h,
 		})
@@ -298,12 +475,14 @@ func scanStaged(cfg Config, scnr scanner.Scanner, emit func([]types.Finding), up
 			continue
 		}
 		if cfg.MaxBytes > 0 && int64(len(data[i])) > cfg.MaxBytes {
+			cfg.coverage.skip(p, SkipMaxBytes, int64(len(data[i])))
 			continue
 		}
+		content, oid := cfg.lfs.resolve(p, "", data[i])
 		jobs = append(jobs, pendingScan{
-			input:    makeBatchInput(p, data[i], nil),
+			input:    makeBatchInput(p, inspectEncrypted(cfg, p, content, emit), lfsContext(nil, oid)),
 			cacheKey: p,
-			cacheVal: fastHash(data[i]),
+			cacheVal: fastHash(content),
 		})
 	}
 	for len(jobs) > 0 {
@@ -328,7 +507,19 @@ func scanHistory(cfg Config, scnr scanner.Scanner, ign ignore.Matcher, emit func
 
 	batchSize := determineBatchSize(cfg.Threads)
 	var jobs []pendingScan
-	for _, e := range entries {
+	// Oldest first, so a secret is first seen in the commit that added it.
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		stamp := func(fs []types.Finding) {
+			for j := range fs {
+				if fs[j].Metadata == nil {
+					fs[j].Metadata = map[string]string{}
+				}
+				fs[j].Metadata["commit"] = e.Hash
+			}
+			emit(fs)
+		}
+		ctx := scanner.ScanContext{Metadata: map[string]string{"commit": e.Hash}}
 		for path, blob := range e.Files {
 			if !allowedByGlobs(path, cfg) {
 				continue
@@ -336,11 +527,13 @@ func scanHistory(cfg Config, scnr scanner.Scanner, ign ignore.Matcher, emit func
 			if ign.Match(path) {
 				continue
 			}
-			if int64(len(blob)) > cfg.MaxBytes {
+			if cfg.MaxBytes > 0 && int64(len(blob)) > cfg.MaxBytes {
+				cfg.coverage.skipAt(path, e.Hash, SkipMaxBytes, int64(len(blob)))
 				continue
 			}
+			blob, oid := cfg.lfs.resolve(path, e.Hash, blob)
 			jobs = append(jobs, pendingScan{
-				input:    makeBatchInput(path, blob, nil),
+				input:    makeBatchInput(path, inspectEncrypted(cfg, path, blob, stamp), lfsContext(&ctx, oid)),
 				cacheKey: path,
 				cacheVal: fastHash(blob),
 			})
@@ -360,30 +553,301 @@ func scanHistory(cfg Config, scnr scanner.Scanner, ign ignore.Matcher, emit func
 	return nil
 }
 
+// scanRevisions scans every unique blob added by the commits selected with
+// --all-history, --range, --since, --until, --author or --branch.
+func scanRevisions(cfg Config, scnr scanner.Scanner, ign ignore.Matcher, emit func([]types.Finding), updated map[string]string, result *Result) error {
+	sel, _ := cfg.revSelection()
+	blobs, err := git.HistoryBlobs(cfg.Root, sel)
+	if err != nil {
+		return err
+	}
+	return scanBlobs(cfg, blobs, scnr, ign, emit, updated, result)
+}
+
+// scanRef scans the tree of cfg.Ref straight from the object database, so
+// tags and other commits can be scanned without a checkout.
+func scanRef(cfg Config, scnr scanner.Scanner, ign ignore.Matcher, emit func([]types.Finding), updated map[string]string, result *Result) error {
+	blobs, err := git.TreeBlobs(cfg.Root, cfg.Ref)
+	if err != nil {
+		return err
+	}
+	stamp := func(fs []types.Finding) {
+		for i := range fs {
+			if fs[i].Metadata == nil {
+				fs[i].Metadata = map[string]string{}
+			}
+			fs[i].Metadata["ref"] = cfg.Ref
+		}
+		emit(fs)
+	}
+	return scanBlobs(cfg, blobs, scnr, ign, stamp, updated, result)
+}
+
+// scanMessages scans the messages of the commits a history scan selected,
+// together with annotated tag messages and git notes attached to those
+// commits. Findings use virtual paths such as commit:<sha>::message.
+func scanMessages(cfg Config, scnr scanner.Scanner, ign ignore.Matcher, emit func([]types.Finding), updated map[string]string) error {
+	sel, ok := cfg.revSelection()
+	if !ok {
+		sel = git.RevSelection{MaxCount: cfg.HistoryCommits}
+	}
+	msgs, err := git.CommitMessages(cfg.Root, sel)
+	if err != nil {
+		return err
+	}
+	selected := make(map[string]bool, len(msgs))
+	for _, m := range msgs {
+		selected[m.Commit] = true
+	}
+	tags, err := git.TagMessages(cfg.Root)
+	if err != nil {
+		return err
+	}
+	notes, err := git.Notes(cfg.Root)
+	if err != nil {
+		return err
+	}
+	for _, m := range append(tags, notes...) {
+		if selected[m.Commit] {
+			msgs = append(msgs, m)
+		}
+	}
+	// Tags named directly (e.g. pushed ones) may not have a ref yet.
+	for _, rev := range cfg.Revisions {
+		m, ok, err := git.TagMessage(cfg.Root, rev)
+		if err != nil {
+			return err
+		}
+		if ok {
+			msgs = append(msgs, m)
+		}
+	}
+
+	jobs := make([]pendingScan, 0, len(msgs))
+	for _, m := range msgs {
+		if !allowedByGlobs(m.Path, cfg) || ign.Match(m.Path) {
+			continue
+		}
+		if cfg.MaxBytes > 0 && int64(len(m.Text)) > cfg.MaxBytes {
+			cfg.coverage.skipAt(m.Path, m.Commit, SkipMaxBytes, int64(len(m.Text)))
+			continue
+		}
+		ctx := scanner.ScanContext{Metadata: map[string]string{"commit": m.Commit}}
+		jobs = append(jobs, pendingScan{input: makeBatchInput(m.Path, m.Text, &ctx)})
+	}
+	// Messages are not files: keep them out of FilesScanned and progress,
+	// which CountTargets sizes by files.
+	var discard Result
+	cfg.Progress, cfg.OnProgress = nil, nil
+	batchSize := determineBatchSize(cfg.Threads)
+	for len(jobs) > 0 {
+		end := min(batchSize, len(jobs))
+		if err := processChunk(scnr, cfg, jobs[:end], emit, updated, &discard); err != nil {
+			return err
+		}
+		jobs = jobs[end:]
+	}
+	return nil
+}
+
+// scanForensics scans blobs that only stashes, reflogs or the unreachable
+// part of the object database still hold.
+func scanForensics(cfg Config, scnr scanner.Scanner, ign ignore.Matcher, emit func([]types.Finding), updated map[string]string, result *Result) error {
+	blobs, err := git.ForensicBlobs(cfg.Root)
+	if err != nil {
+		return err
+	}
+	return scanBlobs(cfg, blobs, scnr, ign, emit, updated, result)
+}
+
+// scanBlobs scans each unique blob exactly once, streaming contents through
+// a single git process, and reports each finding at every commit/path where
+// that blob appears.
+func scanBlobs(cfg Config, blobs []git.HistoryBlob, scnr scanner.Scanner, ign ignore.Matcher, emit func([]types.Finding), updated map[string]string, result *Result) error {
+	refs, ids := historyTargets(blobs, cfg, ign)
+
+	// Findings carry the blob ID in their metadata (from the scan context or
+	// stamped below for structural findings) and are copied to every ref.
+	fanOut := func(fs []types.Finding) {
+		var out []types.Finding
+		for _, f := range fs {
+			rs := refs[f.Metadata["blob"]]
+			if len(rs) == 0 {
+				out = append(out, f)
+				continue
+			}
+			for _, r := range rs {
+				g := f
+				g.Path = r.Path
+				g.Metadata = cloneMetadata(f.Metadata)
+				if r.Commit != "" {
+					g.Metadata["commit"] = r.Commit
+				}
+				if r.Reach != "" {
+					g.Metadata["history_status"] = r.Reach
+				}
+				if r.Stash != "" {
+					g.Metadata["stash"] = r.Stash
+				}
+				out = append(out, g)
+			}
+		}
+		emit(out)
+	}
+
+	batchSize := determineBatchSize(cfg.Threads)
+	var jobs []pendingScan
+	// Blobs over MaxBytes are recorded at every commit/path a finding would
+	// have been reported at.
+	var tooLarge func(id string, size int64)
+	if cfg.coverage != nil {
+		tooLarge = func(id string, size int64) {
+			for _, r := range refs[id] {
+				cfg.coverage.skipAt(r.Path, r.Commit, SkipMaxBytes, size)
+			}
+		}
+	}
+	err := git.ReadBlobsWithSkips(cfg.Root, ids, cfg.MaxBytes, func(id string, blob []byte) error {
+		path := refs[id][0].Path
+		blob, oid := cfg.lfs.resolve(path, refs[id][0].Commit, blob)
+		stamp := func(fs []types.Finding) {
+			for i := range fs {
+				if fs[i].Metadata == nil {
+					fs[i].Metadata = map[string]string{}
+				}
+				fs[i].Metadata["blob"] = id
+			}
+			fanOut(fs)
+		}
+		ctx := scanner.ScanContext{Metadata: map[string]string{"blob": id}}
+		jobs = append(jobs, pendingScan{
+			input: makeBatchInput(path, inspectEncrypted(cfg, path, blob, stamp), lfsContext(&ctx, oid)),
+		})
+		if len(jobs) < batchSize {
+			return nil
+		}
+		chunk := jobs
+		jobs = nil
+		return processChunk(scnr, cfg, chunk, fanOut, updated, result)
+	}, tooLarge)
+	if err != nil {
+		return err
+	}
+	return processChunk(scnr, cfg, jobs, fanOut, updated, result)
+}
+
+// revSelection returns the commit selection for a revision-based history
+// scan and whether one was requested. --history N on its own keeps its
+// existing per-commit path; combined with a selection it caps the number of
+// commits.
+func (cfg Config) revSelection() (git.RevSelection, bool) {
+	sel := git.RevSelection{
+		All:      cfg.AllHistory,
+		Range:    cfg.RevRange,
+		Branches: cfg.Branches,
+		Since:    cfg.Since,
+		Until:    cfg.Until,
+		Author:   cfg.Author,
+
+		Revs:            cfg.Revisions,
+		ExcludeExisting: cfg.ExcludeExisting,
+		Exclude:         cfg.ExcludeRevisions,
+		ExcludeRemote:   cfg.ExcludeRemote,
+	}
+	if !sel.All && sel.Range == "" && len(sel.Branches) == 0 && sel.Since == "" && sel.Until == "" && sel.Author == "" && len(sel.Revs) == 0 {
+		return sel, false
+	}
+	sel.MaxCount = cfg.HistoryCommits
+	return sel, true
+}
+
+// scansWorkingTree reports whether the working tree should be walked, which
+// is only when no history, diff, ref or forensic scan was requested.
+func (cfg Config) scansWorkingTree() bool {
+	_, revs := cfg.revSelection()
+	return !revs && cfg.HistoryCommits == 0 && cfg.BaseBranch == "" && cfg.Ref == "" && !cfg.GitForensics
+}
+
+// loadIgnore loads .redactylignore from the root. When scanning a ref and
+// the root has none (e.g. a bare repository), the ref's own copy is used.
+func loadIgnore(cfg Config) ignore.Matcher {
+	path := filepath.Join(cfg.Root, ".redactylignore")
+	if cfg.Ref != "" {
+		if _, err := os.Stat(path); err != nil {
+			files, _ := git.ReadFilesAt(cfg.Root, cfg.Ref, []string{".redactylignore"})
+			if data, ok := files[".redactylignore"]; ok {
+				return ignore.Parse(data)
+			}
+		}
+	}
+	ign, _ := ignore.Load(path)
+	return ign
+}
+
+// historyTargets drops blob refs excluded by globs or .redactylignore and
+// returns the remaining refs by blob ID along with the IDs to read, in
+// history order.
+func historyTargets(blobs []git.HistoryBlob, cfg Config, ign ignore.Matcher) (map[string][]git.BlobRef, []string) {
+	refs := make(map[string][]git.BlobRef, len(blobs))
+	ids := make([]string, 0, len(blobs))
+	for _, b := range blobs {
+		var keep []git.BlobRef
+		for _, r := range b.Refs {
+			if allowedByGlobs(r.Path, cfg) && !ign.Match(r.Path) {
+				keep = append(keep, r)
+			}
+		}
+		if len(keep) == 0 {
+			continue
+		}
+		refs[b.ID] = keep
+		ids = append(ids, b.ID)
+	}
+	return refs, ids
+}
+
+// scanDiff scans the files changed since cfg.BaseBranch. Each file is
+// scanned in full from the working tree so detectors see surrounding
+// context, and only findings that start on an added line are kept, with
+// their real line numbers. Results are not cached because unchanged lines
+// were not reported.
 func scanDiff(cfg Config, scnr scanner.Scanner, ign ignore.Matcher, emit func([]types.Finding), updated map[string]string, result *Result) error {
-	files, data, err := git.DiffAgainst(cfg.Root, cfg.BaseBranch)
+	diffs, err := git.DiffAddedLines(cfg.Root, cfg.BaseBranch)
 	if err != nil {
 		return err
 	}
 
+	added := map[string]map[int]bool{}
+	onAdded := func(fs []types.Finding) {
+		kept := fs[:0]
+		for _, f := range fs {
+			if added[f.Path][f.Line] {
+				kept = append(kept, f)
+			}
+		}
+		emit(kept)
+	}
 	batchSize := determineBatchSize(cfg.Threads)
-	jobs := make([]pendingScan, 0, len(files))
-	for i, p := range files {
-		if !allowedByGlobs(p, cfg) {
+	jobs := make([]pendingScan, 0, len(diffs))
+	for _, d := range diffs {
+		p := d.Path
+		if len(d.Added) == 0 || !allowedByGlobs(p, cfg) || ign.Match(p) {
 			continue
 		}
-		if ign.Match(p) {
+		data, err := os.ReadFile(filepath.Join(cfg.Root, filepath.FromSlash(p)))
+		if err != nil {
 			continue
 		}
-		if cfg.MaxBytes > 0 && int64(len(data[i])) > cfg.MaxBytes {
+		if cfg.MaxBytes > 0 && int64(len(data)) > cfg.MaxBytes {
+			cfg.coverage.skip(p, SkipMaxBytes, int64(len(data)))
 			continue
 		}
-		trimmed := bytes.TrimSpace(data[i])
-		jobs = append(jobs, pendingScan{
-			input:    makeBatchInput(p, trimmed, nil),
-			cacheKey: p,
-			cacheVal: fastHash(trimmed),
-		})
+		lines := make(map[int]bool, len(d.Added))
+		for _, l := range d.Added {
+			lines[l.Line] = true
+		}
+		added[p] = lines
+		jobs = append(jobs, pendingScan{input: makeBatchInput(p, inspectEncrypted(cfg, p, data, onAdded), nil)})
 	}
 	for len(jobs) > 0 {
 		end := batchSize
@@ -391,7 +855,7 @@ func scanDiff(cfg Config, scnr scanner.Scanner, ign ignore.Matcher, emit func([]
 			end = len(jobs)
 		}
 		chunk := jobs[:end]
-		if err := processChunk(scnr, cfg, chunk, emit, updated, result); err != nil {
+		if err := processChunk(scnr, cfg, chunk, onAdded, updated, result); err != nil {
 			return err
 		}
 		jobs = jobs[end:]
@@ -413,6 +877,7 @@ func scanArtifacts(cfg Config, scnr scanner.Scanner, emit func([]types.Finding),
 	batchSize := determineBatchSize(cfg.Threads)
 	artifactQueue := make([]pendingScan, 0, batchSize)
 	var artifactErr error
+	var kind string // artifact type of the block in progress
 	flushArtifacts := func() {
 		if len(artifactQueue) == 0 {
 			return
@@ -440,6 +905,32 @@ func scanArtifacts(cfg Config, scnr scanner.Scanner, emit func([]types.Finding),
 			input:    makeBatchInput(p, b, &ctx),
 			cacheKey: p,
 			cacheVal: fastHash(b),
+			kind:     kind,
+		})
+		if len(artifactQueue) >= batchSize {
+			flushArtifacts()
+		}
+	}
+	// Selectively extracted fragments are reported against the real file at
+	// their original position; they are not cached since the walk owns the
+	// cache entry for that path.
+	extractArtifact := func(x artifacts.Extract) {
+		if artifactErr != nil {
+			return
+		}
+		if cfg.DryRun {
+			return
+		}
+		ctx := scanner.ScanContext{
+			VirtualPath:  x.Path,
+			RealPath:     x.Path,
+			Metadata:     x.Metadata,
+			LineOffset:   x.Line - 1,
+			ColumnOffset: x.Column - 1,
+		}
+		artifactQueue = append(artifactQueue, pendingScan{
+			input: makeBatchInput(x.Path, x.Data, &ctx),
+			kind:  kind,
 		})
 		if len(artifactQueue) >= batchSize {
 			flushArtifacts()
@@ -447,33 +938,46 @@ func scanArtifacts(cfg Config, scnr scanner.Scanner, emit func([]types.Finding),
 	}
 	allowArtifact := func(rel string) bool { return allowedByGlobs(rel, cfg) }
 	var artStats artifacts.Stats
+	// Each block's jobs are flushed before the next so they are counted
+	// under its artifact type.
+	begin := func(k string) {
+		flushArtifacts()
+		kind = k
+	}
 
 	if cfg.ScanArchives {
+		begin(KindArchive)
 		if err := artifacts.ScanArchivesWithStats(cfg.Root, lim, allowArtifact, emitArtifact, &artStats); err != nil {
 			result.ArtifactErrors = append(result.ArtifactErrors, err)
 		}
 	}
 	if cfg.ScanContainers {
+		begin(KindContainer)
 		if err := artifacts.ScanContainersWithStats(cfg.Root, lim, allowArtifact, emitArtifact, &artStats); err != nil {
 			result.ArtifactErrors = append(result.ArtifactErrors, err)
 		}
 	}
 	if cfg.ScanIaC {
-		if err := artifacts.ScanIaCWithFilter(cfg.Root, lim, allowArtifact, emitArtifact); err != nil {
+		begin(KindIaC)
+		reportIaC := func(f types.Finding) { emitStructural(cfg, []types.Finding{f}, emit) }
+		if err := artifacts.ScanIaCWithFindings(cfg.Root, lim, allowArtifact, emitArtifact, extractArtifact, reportIaC); err != nil {
 			result.ArtifactErrors = append(result.ArtifactErrors, err)
 		}
 	}
 	if cfg.ScanHelm {
+		begin(KindHelm)
 		if err := artifacts.ScanHelmChartsWithFilter(cfg.Root, lim, allowArtifact, emitArtifact); err != nil {
 			result.ArtifactErrors = append(result.ArtifactErrors, err)
 		}
 	}
 	if cfg.ScanK8s {
+		begin(KindK8s)
 		if err := artifacts.ScanK8sManifestsWithFilter(cfg.Root, lim, allowArtifact, emitArtifact); err != nil {
 			result.ArtifactErrors = append(result.ArtifactErrors, err)
 		}
 	}
 	if len(cfg.RegistryImages) > 0 {
+		begin(KindRegistry)
 		for _, img := range cfg.RegistryImages {
 			if err := artifacts.ScanRegistryImage(img, lim, emitArtifact, &artStats); err != nil {
 				result.ArtifactErrors = append(result.ArtifactErrors, err)
@@ -490,9 +994,44 @@ func scanArtifacts(cfg Config, scnr scanner.Scanner, emit func([]types.Finding),
 		AbortedByDepth:   artStats.AbortedByDepth,
 		AbortedByTime:    artStats.AbortedByTime,
 	}
+	cfg.coverage.addAborts(cfg.Root, artStats.Aborts)
 	return nil
 }
 
+// dedupeFindings drops repeated reports of the same match at the same
+// location (and commit, for history scans), e.g. when --iac extracts a
+// value the filesystem walk already reported. The first occurrence is kept and gains any metadata keys (such
+// as iac_address) that only the later reports carried.
+func dedupeFindings(fs []types.Finding) []types.Finding {
+	if len(fs) < 2 {
+		return fs
+	}
+	seen := make(map[string]int, len(fs))
+	out := fs[:0]
+	for _, f := range fs {
+		key := dedupeKey(f)
+		if i, ok := seen[key]; ok {
+			for k, v := range f.Metadata {
+				if _, exists := out[i].Metadata[k]; !exists {
+					if out[i].Metadata == nil {
+						out[i].Metadata = map[string]string{}
+					}
+					out[i].Metadata[k] = v
+				}
+			}
+			continue
+		}
+		seen[key] = len(out)
+		out = append(out, f)
+	}
+	return out
+}
+
+// dedupeKey identifies a finding for dedupeFindings and OnFinding.
+func dedupeKey(f types.Finding) string {
+	return fmt.Sprintf("%s\x00%d\x00%d\x00%s\x00%s\x00%s", f.Path, f.Line, f.Column, f.Detector, f.Match, f.Metadata["commit"])
+}
+
 func fastHash(b []byte) string {
 	if len(b) == 0 {
 		return "0000000000000000"
@@ -507,6 +1046,12 @@ func fastHash(b []byte) string {
 	return string(buf[:])
 }
 
+// FilterFindings applies the confidence and detector filters a scan would,
+// for findings loaded from a previous scan.
+func FilterFindings(fs []types.Finding, minConfidence float64, enable, disable string) []types.Finding {
+	return filterByIDs(filterByConfidence(fs, minConfidence), enable, disable)
+}
+
 func filterByConfidence(fs []types.Finding, min float64) []types.Finding {
 	if min <= 0 {
 		return fs
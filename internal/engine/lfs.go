@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"os"
+
+	"github.com/varalys/redactyl/internal/git"
+	"github.com/varalys/redactyl/internal/scanner"
+)
+
+// MissingLFSObject is a Git LFS pointer whose object is not in the local
+// store, so its real content could not be scanned.
+type MissingLFSObject struct {
+	Path   string `json:"path"`
+	OID    string `json:"oid"`
+	Size   int64  `json:"size"`
+	Commit string `json:"commit,omitempty"` // set for pointers found in history
+}
+
+// lfsResolver swaps Git LFS pointer files for their objects from the local
+// store. A nil resolver leaves content unchanged.
+type lfsResolver struct {
+	cfg     Config
+	dir     string
+	missing []MissingLFSObject
+}
+
+func newLFSResolver(cfg Config) *lfsResolver {
+	if !cfg.LFS {
+		return nil
+	}
+	dir, err := git.LFSObjectsDir(cfg.Root)
+	if err != nil {
+		// Not a git repository: every pointer is reported as missing.
+		dir = ""
+	}
+	return &lfsResolver{cfg: cfg, dir: dir}
+}
+
+// resolve returns the content to scan for a file and, when the file is an
+// LFS pointer whose object was substituted, the object's ID. Pointers whose
+// object is not available locally are recorded and scanned as they are;
+// objects over MaxBytes or that look binary are scanned the same way, and
+// recorded as coverage skips.
+func (l *lfsResolver) resolve(path, commit string, data []byte) ([]byte, string) {
+	if l == nil {
+		return data, ""
+	}
+	p, ok := git.ParseLFSPointer(data)
+	if !ok {
+		return data, ""
+	}
+	if l.cfg.MaxBytes > 0 && p.Size > l.cfg.MaxBytes {
+		l.cfg.coverage.skipAt(path, commit, SkipMaxBytes, p.Size)
+		return data, ""
+	}
+	var obj []byte
+	err := os.ErrNotExist
+	if l.dir != "" {
+		obj, err = os.ReadFile(p.ObjectPath(l.dir))
+	}
+	if err != nil {
+		l.missing = append(l.missing, MissingLFSObject{Path: path, OID: p.OID, Size: p.Size, Commit: commit})
+		return data, ""
+	}
+	if looksBinary(obj) {
+		l.cfg.coverage.skipAt(path, commit, SkipBinary, int64(len(obj)))
+		return data, ""
+	}
+	if looksNonTextMIME(path, obj) {
+		l.cfg.coverage.skipAt(path, commit, SkipMIME, int64(len(obj)))
+		return data, ""
+	}
+	return obj, p.OID
+}
+
+// lfsContext adds the LFS object ID to a scan context, so findings in an
+// object's content record which object they came from.
+func lfsContext(ctx *scanner.ScanContext, oid string) *scanner.ScanContext {
+	if oid == "" {
+		return ctx
+	}
+	out := scanner.ScanContext{}
+	if ctx != nil {
+		out = *ctx
+	}
+	out.Metadata = cloneMetadata(out.Metadata)
+	out.Metadata["lfs_oid"] = oid
+	return &out
+}
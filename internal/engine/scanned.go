@@ -0,0 +1,39 @@
+package engine
+
+// Scan modes recorded in Result.Scanned.
+const (
+	ModeFilesystem = "filesystem" // working tree and staged changes
+	ModeHistory    = "history"    // commits, --ref, --base and git forensics
+	ModeArtifacts  = "artifacts"  // archives, containers, IaC, Helm, Kubernetes and registry images
+)
+
+// ScannedPath is one path handed to the detectors. Artifact entries use
+// virtual paths (e.g. image.tar::<layer>/etc/app.env).
+type ScannedPath struct {
+	Mode string `json:"mode"`
+	Path string `json:"path"`
+}
+
+// ProgressEvent reports one scanned file to Config.OnProgress.
+type ProgressEvent struct {
+	Mode         string `json:"mode"`
+	Path         string `json:"path"`
+	FilesScanned int    `json:"files_scanned"` // files scanned so far, this one included
+}
+
+// dedupeScanned drops repeats, such as a history path scanned at several
+// commits, keeping first-seen order.
+func dedupeScanned(in []ScannedPath) []ScannedPath {
+	if len(in) == 0 {
+		return in
+	}
+	seen := make(map[ScannedPath]bool, len(in))
+	out := in[:0]
+	for _, s := range in {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
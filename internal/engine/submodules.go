@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/varalys/redactyl/internal/git"
+	"github.com/varalys/redactyl/internal/ignore"
+	"github.com/varalys/redactyl/internal/types"
+)
+
+// scansSubmodules reports whether submodules should be scanned: working-tree,
+// history and forensic scans recurse into them; staged, --base and --ref
+// scans do not.
+func (cfg Config) scansSubmodules() bool {
+	_, history := cfg.revSelection()
+	return cfg.Submodules && (cfg.scansWorkingTree() || history || cfg.HistoryCommits > 0 || cfg.GitForensics)
+}
+
+// submoduleConfig derives the configuration for scanning the submodule at
+// root. Revisions and ranges name superproject commits, so a history scan
+// that uses them walks the submodule's HEAD instead, keeping the date and
+// author filters. Include/exclude globs are applied afterwards to
+// superproject paths.
+func (cfg Config) submoduleConfig(root string) Config {
+	_, history := cfg.revSelection()
+	history = history || cfg.HistoryCommits > 0
+	n := cfg
+	n.Root = root
+	n.IncludeGlobs, n.ExcludeGlobs = "", ""
+	n.ScanStaged, n.BaseBranch, n.Ref = false, "", ""
+	n.RevRange, n.Branches, n.Revisions = "", nil, nil
+	n.ExcludeExisting, n.ExcludeRevisions, n.ExcludeRemote = false, nil, ""
+	if _, ok := n.revSelection(); history && !ok && n.HistoryCommits == 0 {
+		n.Revisions = []string{"HEAD"}
+	}
+	// The submodule's files are not the superproject's artifacts, and its
+	// cache would be written into its working tree.
+	n.ScanArchives, n.ScanContainers, n.ScanIaC, n.ScanHelm, n.ScanK8s = false, false, false, false, false
+	n.RegistryImages = nil
+	n.NoCache = true
+	// Findings and progress are reported by the superproject scan under
+	// the submodule's path.
+	n.OnFinding, n.OnProgress = nil, nil
+	return n
+}
+
+// scanSubmodules scans each initialised submodule and reports its findings
+// under the submodule's path, with submodule and submodule_commit metadata.
+// Nested submodules are scanned recursively.
+func scanSubmodules(cfg Config, ign ignore.Matcher, emit func([]types.Finding), result *Result) error {
+	subs, err := git.Submodules(cfg.Root)
+	if err != nil {
+		return err
+	}
+	for _, s := range subs {
+		if !s.Initialized() {
+			continue
+		}
+		res, err := ScanWithStats(cfg.submoduleConfig(filepath.Join(cfg.Root, filepath.FromSlash(s.Path))))
+		if err != nil {
+			return fmt.Errorf("submodule %s: %w", s.Path, err)
+		}
+		result.FilesScanned += res.FilesScanned
+		for _, sp := range res.Scanned {
+			if !git.IsMessagePath(sp.Path) {
+				sp.Path = path.Join(s.Path, sp.Path)
+			}
+			if !allowedByGlobs(sp.Path, cfg) || ign.Match(sp.Path) {
+				continue
+			}
+			result.Scanned = append(result.Scanned, sp)
+		}
+		result.Coverage.merge(res.Coverage, s.Path)
+		for _, m := range res.MissingLFS {
+			m.Path = path.Join(s.Path, m.Path)
+			result.MissingLFS = append(result.MissingLFS, m)
+		}
+		var out []types.Finding
+		for _, f := range res.Findings {
+			if !git.IsMessagePath(f.Path) {
+				f.Path = path.Join(s.Path, f.Path)
+			}
+			if !allowedByGlobs(f.Path, cfg) || ign.Match(f.Path) {
+				continue
+			}
+			f.Metadata = cloneMetadata(f.Metadata)
+			// Findings from a nested submodule already name it relative to s.
+			if inner := f.Metadata["submodule"]; inner != "" {
+				f.Metadata["submodule"] = path.Join(s.Path, inner)
+			} else {
+				f.Metadata["submodule"] = s.Path
+				f.Metadata["submodule_commit"] = s.Head
+			}
+			out = append(out, f)
+		}
+		emit(out)
+	}
+	return nil
+}
+
+// submoduleDirs returns the initialised submodules of the repository at root
+// as slash-separated paths, for the working-tree walk to skip.
+func submoduleDirs(root string) map[string]bool {
+	subs, _ := git.Submodules(root)
+	dirs := make(map[string]bool, len(subs))
+	for _, s := range subs {
+		if s.Initialized() {
+			dirs[s.Path] = true
+		}
+	}
+	return dirs
+}
@@ -13,7 +13,12 @@ import (
 )
 
 // Walk traverses the working tree and invokes handle for each eligible file.
+// With Submodules set, initialised submodules are left to scanSubmodules.
 func Walk(ctx context.Context, cfg Config, ign ignore.Matcher, handle func(path string, data []byte)) error {
+	var subs map[string]bool
+	if cfg.Submodules {
+		subs = submoduleDirs(cfg.Root)
+	}
 	return filepath.WalkDir(cfg.Root, func(p string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil
@@ -21,34 +26,50 @@ func Walk(ctx context.Context, cfg Config, ign ignore.Matcher, handle func(path
 		if d.IsDir() {
 			name := d.Name()
 			if cfg.DefaultExcludes && isDefaultDirExcluded(name) {
+				rel, _ := filepath.Rel(cfg.Root, p)
+				cfg.coverage.skipDir(p, rel, SkipDefaultExclude)
+				return filepath.SkipDir
+			}
+			if rel, _ := filepath.Rel(cfg.Root, p); subs[filepath.ToSlash(rel)] {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 		rel, _ := filepath.Rel(cfg.Root, p)
-		if !allowedByGlobs(rel, cfg) {
+		info, _ := d.Info()
+		var size int64
+		if info != nil {
+			size = info.Size()
+		}
+		skip := func(reason string) error {
+			cfg.coverage.skip(filepath.ToSlash(rel), reason, size)
 			return nil
 		}
+		if !allowedByGlobs(rel, cfg) {
+			return skip(SkipGlob)
+		}
 		if ign.Match(rel) {
-			return nil
+			return skip(SkipIgnoreFile)
 		}
-		info, _ := d.Info()
-		if info != nil && info.Size() > cfg.MaxBytes {
-			return nil
+		if info != nil && size > cfg.MaxBytes {
+			return skip(SkipMaxBytes)
 		}
 		lower := strings.ToLower(rel)
 		if cfg.DefaultExcludes && isDefaultFileExcluded(lower) {
-			return nil
+			return skip(SkipDefaultExclude)
 		}
 		b, err := os.ReadFile(p)
 		if err != nil {
-			return nil
+			return skip(SkipUnreadable)
 		}
 		if strings.Contains(string(b), "redactyl:ignore-file") {
-			return nil
+			return skip(SkipIgnoreMarker)
 		}
-		if looksBinary(b) || looksNonTextMIME(rel, b) {
-			return nil
+		if looksBinary(b) {
+			return skip(SkipBinary)
+		}
+		if looksNonTextMIME(rel, b) {
+			return skip(SkipMIME)
 		}
 		handle(rel, b)
 		return nil
@@ -90,9 +111,34 @@ func looksNonTextMIME(path string, b []byte) bool {
 }
 
 func CountTargets(cfg Config) (int, error) {
-	ign, errIgnore := ignore.Load(filepath.Join(cfg.Root, ".redactylignore"))
-	if errIgnore != nil {
-		return 0, nil
+	ign := loadIgnore(cfg)
+	if sel, ok := cfg.revSelection(); ok || cfg.Ref != "" || cfg.GitForensics {
+		n := 0
+		if ok {
+			blobs, err := git.HistoryBlobs(cfg.Root, sel)
+			if err != nil {
+				return 0, nil
+			}
+			_, ids := historyTargets(blobs, cfg, ign)
+			n += len(ids)
+		}
+		if cfg.Ref != "" {
+			blobs, err := git.TreeBlobs(cfg.Root, cfg.Ref)
+			if err != nil {
+				return 0, nil
+			}
+			_, ids := historyTargets(blobs, cfg, ign)
+			n += len(ids)
+		}
+		if cfg.GitForensics {
+			blobs, err := git.ForensicBlobs(cfg.Root)
+			if err != nil {
+				return 0, nil
+			}
+			_, ids := historyTargets(blobs, cfg, ign)
+			n += len(ids)
+		}
+		return n, nil
 	}
 	if cfg.HistoryCommits > 0 {
 		entries, err := git.LastNCommits(cfg.Root, cfg.HistoryCommits)
@@ -117,22 +163,22 @@ func CountTargets(cfg Config) (int, error) {
 		return n, nil
 	}
 	if cfg.BaseBranch != "" {
-		files, data, err := git.DiffAgainst(cfg.Root, cfg.BaseBranch)
+		diffs, err := git.DiffAddedLines(cfg.Root, cfg.BaseBranch)
 		if err != nil {
 			return 0, nil
 		}
 		n := 0
-		for i, p := range files {
-			if len(data[i]) == 0 { // skip pure deletions/renames with no added lines
+		for _, d := range diffs {
+			if len(d.Added) == 0 { // skip pure deletions/renames with no added lines
 				continue
 			}
-			if !allowedByGlobs(p, cfg) {
+			if !allowedByGlobs(d.Path, cfg) {
 				continue
 			}
-			if ign.Match(p) {
+			if ign.Match(d.Path) {
 				continue
 			}
-			if int64(len(data[i])) > cfg.MaxBytes {
+			if fi, err := os.Stat(filepath.Join(cfg.Root, filepath.FromSlash(d.Path))); err != nil || (cfg.MaxBytes > 0 && fi.Size() > cfg.MaxBytes) {
 				continue
 			}
 			n++
@@ -0,0 +1,187 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// BlobRef is a commit and path at which a blob was added or modified.
+type BlobRef struct {
+	Commit string
+	Path   string
+	Reach  string // for ForensicBlobs: ReachStash, ReachReflog or ReachUnreachable
+	Stash  string // for stash refs: the entry name, e.g. stash@{0}
+}
+
+// HistoryBlob is a unique blob from history together with every commit and
+// path where it appears.
+type HistoryBlob struct {
+	ID   string
+	Refs []BlobRef
+}
+
+// historyRefs selects the commits reachable from every local branch, tag and
+// remote-tracking branch.
+var historyRefs = []string{"--branches", "--tags", "--remotes"}
+
+// parseRawLog parses `git log --raw -z --format="commit %H"` output.
+func parseRawLog(out []byte) []HistoryBlob {
+	var blobs []HistoryBlob
+	index := map[string]int{}
+	seen := map[BlobRef]map[string]bool{}
+	commit := ""
+	tokens := bytes.Split(out, []byte{0})
+	for i := 0; i < len(tokens); i++ {
+		tok := strings.TrimLeft(string(tokens[i]), "\n")
+		switch {
+		case strings.HasPrefix(tok, "commit "):
+			commit = strings.TrimPrefix(tok, "commit ")
+		case strings.HasPrefix(tok, ":") && i+1 < len(tokens):
+			i++
+			path := string(tokens[i])
+			// :<old mode> <new mode> <old sha> <new sha> <status>
+			fields := strings.Fields(tok[1:])
+			if len(fields) < 5 || commit == "" {
+				continue
+			}
+			mode, id, status := fields[1], fields[3], fields[4]
+			if status == "D" || strings.Trim(id, "0") == "" || mode == "160000" || mode == "120000" {
+				continue
+			}
+			ref := BlobRef{Commit: commit, Path: path}
+			// merges are listed once per parent with -m
+			if seen[ref][id] {
+				continue
+			}
+			if seen[ref] == nil {
+				seen[ref] = map[string]bool{}
+			}
+			seen[ref][id] = true
+			n, ok := index[id]
+			if !ok {
+				n = len(blobs)
+				index[id] = n
+				blobs = append(blobs, HistoryBlob{ID: id})
+			}
+			blobs[n].Refs = append(blobs[n].Refs, ref)
+		}
+	}
+	return blobs
+}
+
+// ReadBlobsWithSkips streams the contents of ids through a single
+// `git cat-file --batch` process, calling fn for each blob in order. Blobs
+// larger than maxBytes (when positive) are skipped without being buffered,
+// and reported to skipped, when non-nil, with their ID and size. An error
+// returned by fn stops the stream and is returned.
+func ReadBlobsWithSkips(root string, ids []string, maxBytes int64, fn func(id string, data []byte) error, skipped func(id string, size int64)) error {
+	var tooLarge func(i int, size int64)
+	if skipped != nil {
+		tooLarge = func(i int, size int64) { skipped(ids[i], size) }
+	}
+	return catFileBatch(root, ids, maxBytes, func(i int, data []byte) error {
+		return fn(ids[i], data)
+	}, tooLarge)
+}
+
+// ReadFilesAt returns the contents of paths as of rev (e.g. HEAD). Paths
+// that do not exist at rev are absent from the result.
+func ReadFilesAt(root, rev string, paths []string) (map[string][]byte, error) {
+	names := make([]string, len(paths))
+	for i, p := range paths {
+		names[i] = rev + ":" + p
+	}
+	out := make(map[string][]byte, len(paths))
+	err := catFileBatch(root, names, 0, func(i int, data []byte) error {
+		out[paths[i]] = data
+		return nil
+	}, nil)
+	return out, err
+}
+
+// catFileBatch resolves names with one `git cat-file --batch` process and
+// calls fn with the index of each name that resolved to a blob. Missing
+// objects, non-blobs and blobs over maxBytes (when positive) are skipped;
+// tooLarge, when non-nil, is called with the index and size of the latter.
+func catFileBatch(root string, names []string, maxBytes int64, fn func(i int, data []byte) error, tooLarge func(i int, size int64)) error {
+	if len(names) == 0 {
+		return nil
+	}
+	validRoot, err := validateRoot(root)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("git", "-C", validRoot, "cat-file", "--batch")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() {
+		w := bufio.NewWriter(stdin)
+		for _, name := range names {
+			if _, err := w.WriteString(name + "\n"); err != nil {
+				break
+			}
+		}
+		_ = w.Flush()
+		_ = stdin.Close()
+	}()
+
+	r := bufio.NewReaderSize(stdout, 64<<10)
+	readErr := func() error {
+		for i := range names {
+			header, err := r.ReadString('\n')
+			if err != nil {
+				return fmt.Errorf("cat-file: %w", err)
+			}
+			// <sha> <type> <size>, or <name> missing (names may contain spaces)
+			header = strings.TrimSpace(header)
+			if strings.HasSuffix(header, " missing") || strings.HasSuffix(header, " ambiguous") {
+				continue
+			}
+			fields := strings.Fields(header)
+			if len(fields) != 3 {
+				continue
+			}
+			size, err := strconv.ParseInt(fields[2], 10, 64)
+			if err != nil {
+				return fmt.Errorf("cat-file: bad header %q", header)
+			}
+			if fields[1] != "blob" || (maxBytes > 0 && size > maxBytes) {
+				if _, err := r.Discard(int(size) + 1); err != nil {
+					return fmt.Errorf("cat-file: %w", err)
+				}
+				if fields[1] == "blob" && tooLarge != nil {
+					tooLarge(i, size)
+				}
+				continue
+			}
+			data := make([]byte, size+1)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return fmt.Errorf("cat-file: %w", err)
+			}
+			if err := fn(i, data[:size]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+	if readErr != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return readErr
+	}
+	return cmd.Wait()
+}
@@ -0,0 +1,99 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHistoryBlobs_All(t *testing.T) {
+	dir := initRepo(t)
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, string(out))
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.txt", "shared\n")
+	run("add", "a.txt")
+	run("commit", "-m", "add a")
+	main := run("rev-parse", "--abbrev-ref", "HEAD")
+
+	// Same content under another path on a side branch, later deleted.
+	run("checkout", "-b", "feature")
+	write("b file.txt", "shared\n")
+	write("secret.txt", "only on feature\n")
+	run("add", ".")
+	run("commit", "-m", "feature work")
+	side := run("rev-parse", "HEAD")
+	run("rm", "-q", "secret.txt")
+	run("commit", "-m", "remove secret")
+	run("checkout", main)
+	run("branch", "-D", "feature")
+	run("tag", "keep", side)
+
+	blobs, err := HistoryBlobs(dir, RevSelection{All: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blobs) != 2 {
+		t.Fatalf("expected 2 unique blobs, got %+v", blobs)
+	}
+	shared := blobs[0]
+	if len(shared.Refs) != 2 || shared.Refs[0].Path != "a.txt" || shared.Refs[1].Path != "b file.txt" || shared.Refs[1].Commit != side {
+		t.Fatalf("expected shared blob at a.txt and b file.txt, got %+v", shared.Refs)
+	}
+	if blobs[1].Refs[0].Path != "secret.txt" {
+		t.Fatalf("expected tagged-only blob to be found, got %+v", blobs[1])
+	}
+
+	got := map[string]string{}
+	ids := []string{shared.ID, blobs[1].ID}
+	if err := ReadBlobsWithSkips(dir, ids, 0, func(id string, data []byte) error {
+		got[id] = string(data)
+		return nil
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got[shared.ID] != "shared\n" || got[blobs[1].ID] != "only on feature\n" {
+		t.Fatalf("unexpected blob contents %q", got)
+	}
+
+	got = map[string]string{}
+	skipped := map[string]int64{}
+	if err := ReadBlobsWithSkips(dir, ids, 10, func(id string, data []byte) error {
+		got[id] = string(data)
+		return nil
+	}, func(id string, size int64) { skipped[id] = size }); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[shared.ID] == "" {
+		t.Fatalf("expected blobs over maxBytes to be skipped, got %q", got)
+	}
+	if len(skipped) != 1 || skipped[blobs[1].ID] != int64(len("only on feature\n")) {
+		t.Fatalf("expected the skipped blob and its size to be reported, got %v", skipped)
+	}
+}
+
+func TestParseRawLog_MergeParents(t *testing.T) {
+	blob := strings.Repeat("1", 40)
+	zero := strings.Repeat("0", 40)
+	entry := ":100644 100644 " + zero + " " + blob + " M\x00f.txt\x00"
+	out := "commit c1\x00\n" + entry + "commit c1\x00\n" + entry +
+		"commit c2\x00\n:100644 000000 " + blob + " " + zero + " D\x00f.txt\x00" +
+		"commit c3\x00\n:000000 160000 " + zero + " " + strings.Repeat("2", 40) + " A\x00sub\x00"
+	blobs := parseRawLog([]byte(out))
+	if len(blobs) != 1 || len(blobs[0].Refs) != 1 || blobs[0].Refs[0] != (BlobRef{Commit: "c1", Path: "f.txt"}) {
+		t.Fatalf("unexpected blobs %+v", blobs)
+	}
+}
@@ -0,0 +1,80 @@
+package git
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CloneOptions controls MirrorClone.
+type CloneOptions struct {
+	Depth        int    // fetch only the most recent N commits of each ref (0=full history)
+	SingleBranch bool   // fetch only the remote's default branch, or Branch
+	Branch       string // fetch only this branch; implies SingleBranch
+}
+
+// MirrorClone makes a bare mirror clone of source, a remote URL, file:// URL
+// or local repository path, into dir. Local paths are cloned through a
+// file:// URL so Depth applies to them too. Credentials embedded in source
+// are removed from the clone's origin URL and never appear in errors.
+func MirrorClone(source, dir string, opts CloneOptions) error {
+	if source == "" || strings.HasPrefix(source, "-") || strings.ContainsAny(source, "\x00\n") {
+		return fmt.Errorf("invalid repository %q", source)
+	}
+	if opts.Branch != "" {
+		if err := validateRev(opts.Branch); err != nil {
+			return err
+		}
+	}
+	if st, err := os.Stat(source); err == nil && st.IsDir() {
+		abs, err := filepath.Abs(source)
+		if err != nil {
+			return err
+		}
+		source = (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+	}
+	args := []string{"clone", "--mirror", "--quiet"}
+	if opts.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(opts.Depth))
+	}
+	switch {
+	case opts.SingleBranch || opts.Branch != "":
+		args = append(args, "--single-branch")
+	case opts.Depth > 0:
+		// --depth implies --single-branch; keep every ref unless asked.
+		args = append(args, "--no-single-branch")
+	}
+	if opts.Branch != "" {
+		args = append(args, "--branch", opts.Branch)
+	}
+	args = append(args, "--", source, dir)
+	cmd := exec.Command("git", args...)
+	// Never block on a credential prompt.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		msg := strings.ReplaceAll(strings.TrimSpace(string(out)), source, RedactURL(source))
+		return fmt.Errorf("clone %s: %w: %s", RedactURL(source), err, msg)
+	}
+	if redacted := RedactURL(source); redacted != source {
+		if out, err := exec.Command("git", "-C", dir, "config", "remote.origin.url", redacted).CombinedOutput(); err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// RedactURL removes user information, such as an access token, from a
+// repository URL. scp-style addresses (git@host:org/repo) and paths are
+// returned unchanged.
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.User == nil {
+		return raw
+	}
+	u.User = nil
+	return u.String()
+}
@@ -0,0 +1,110 @@
+package git
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// CommitInfo describes who made a commit, when, and why.
+type CommitInfo struct {
+	Hash        string
+	Author      string
+	AuthorEmail string
+	AuthorDate  time.Time
+	Subject     string
+}
+
+// CommitInfos looks up author and subject details for hashes with a single
+// `git log --no-walk` call. Unknown hashes are absent from the result.
+func CommitInfos(root string, hashes []string) (map[string]CommitInfo, error) {
+	out := make(map[string]CommitInfo, len(hashes))
+	if len(hashes) == 0 {
+		return out, nil
+	}
+	validRoot, err := validateRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command("git", "-C", validRoot, "log", "--no-walk=unsorted", "--stdin", "-z", "--format=%H%x00%an%x00%ae%x00%aI%x00%s")
+	cmd.Stdin = strings.NewReader(strings.Join(hashes, "\n") + "\n")
+	b, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	fields := bytes.Split(bytes.TrimRight(b, "\x00\n"), []byte{0})
+	for i := 0; i+4 < len(fields); i += 5 {
+		ci := CommitInfo{
+			Hash:        strings.TrimLeft(string(fields[i]), "\n"),
+			Author:      string(fields[i+1]),
+			AuthorEmail: string(fields[i+2]),
+			Subject:     string(fields[i+4]),
+		}
+		ci.AuthorDate, _ = time.Parse(time.RFC3339, string(fields[i+3]))
+		out[ci.Hash] = ci
+	}
+	return out, nil
+}
+
+// ReachableFrom returns the set of commits reachable from rev. An unborn or
+// unknown rev yields an empty set.
+func ReachableFrom(root, rev string) (map[string]bool, error) {
+	validRoot, err := validateRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	set := map[string]bool{}
+	b, err := exec.Command("git", "-C", validRoot, "rev-list", rev, "--").Output()
+	if err != nil {
+		return set, nil
+	}
+	for _, h := range strings.Fields(string(b)) {
+		set[h] = true
+	}
+	return set, nil
+}
+
+// TopoOrder returns the position of each of hashes in the topological order
+// of the history leading to them, ancestors first, so a commit always ranks
+// before its descendants whatever its author or committer date. Unknown
+// hashes are absent from the result.
+func TopoOrder(root string, hashes []string) (map[string]int, error) {
+	out := make(map[string]int, len(hashes))
+	if len(hashes) == 0 {
+		return out, nil
+	}
+	validRoot, err := validateRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	want := make(map[string]bool, len(hashes))
+	for _, h := range hashes {
+		want[h] = true
+	}
+	cmd := exec.Command("git", "-C", validRoot, "rev-list", "--topo-order", "--reverse", "--ignore-missing", "--stdin")
+	cmd.Stdin = strings.NewReader(strings.Join(hashes, "\n") + "\n")
+	b, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range strings.Fields(string(b)) {
+		if want[h] {
+			out[h] = len(out)
+		}
+	}
+	return out, nil
+}
+
+// RemoteURL returns the URL of the origin remote, or "" when none is set.
+func RemoteURL(root string) string {
+	validRoot, err := validateRoot(root)
+	if err != nil {
+		return ""
+	}
+	b, err := exec.Command("git", "-C", validRoot, "config", "--get", "remote.origin.url").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
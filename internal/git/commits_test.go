@@ -0,0 +1,81 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommitAttributionHelpers(t *testing.T) {
+	dir := initRepo(t)
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, string(out))
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a file.txt", "base\n")
+	run("add", ".")
+	run("commit", "-m", "base")
+	run("branch", "base")
+	write("a file.txt", "base\nSECRET\n")
+	run("add", ".")
+	run("commit", "-m", "add secret", "-m", "body text")
+	added := run("rev-parse", "HEAD")
+	write("a file.txt", "base\nSECRET\nmore\n")
+	run("add", ".")
+	run("commit", "-m", "unrelated edit")
+
+	infos, err := CommitInfos(dir, []string{added})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ci := infos[added]
+	if ci.Author != "tester" || ci.AuthorEmail != "test@example.com" || ci.Subject != "add secret" || ci.AuthorDate.IsZero() {
+		t.Fatalf("unexpected commit info %+v", ci)
+	}
+
+	patches, err := RangeAddedLines(dir, "base..HEAD", []string{"a file.txt"})
+	if err != nil || len(patches) != 2 || patches[0].Hash != added {
+		t.Fatalf("expected two patches starting at %s, got %+v (%v)", added, patches, err)
+	}
+	if f := patches[0].Files; len(f) != 1 || f[0].Path != "a file.txt" || len(f[0].Added) != 1 || f[0].Added[0] != (AddedLine{Line: 2, Text: "SECRET"}) {
+		t.Fatalf("unexpected added lines %+v", f)
+	}
+	if f := patches[1].Files; len(f) != 1 || len(f[0].Added) != 1 || f[0].Added[0].Text != "more" {
+		t.Fatalf("unexpected added lines %+v", f)
+	}
+
+	// Ancestry, not dates: the later commit backdated before its parent
+	// still ranks after it.
+	run("commit", "--allow-empty", "-m", "backdated", "--date", "2001-01-01T00:00:00Z")
+	backdated := run("rev-parse", "HEAD")
+	order, err := TopoOrder(dir, []string{backdated, added, "0000000000000000000000000000000000000000"})
+	if err != nil || len(order) != 2 || order[added] >= order[backdated] {
+		t.Fatalf("expected %s before %s, got %v (%v)", added, backdated, order, err)
+	}
+	run("reset", "-q", "--hard", "HEAD~1")
+
+	head, err := ReachableFrom(dir, "HEAD")
+	if err != nil || !head[added] || len(head) != 3 {
+		t.Fatalf("expected 3 commits reachable from HEAD, got %v (%v)", head, err)
+	}
+
+	files, err := ReadFilesAt(dir, "HEAD", []string{"missing.txt", "a file.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || string(files["a file.txt"]) != "base\nSECRET\nmore\n" {
+		t.Fatalf("unexpected files at HEAD %q", files)
+	}
+}
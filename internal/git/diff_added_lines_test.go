@@ -8,7 +8,7 @@ import (
 	"testing"
 )
 
-func TestDiffAgainst_OnlyAddedLines(t *testing.T) {
+func TestDiffAddedLines_OnlyAddedLines(t *testing.T) {
 	dir := t.TempDir()
 	run := func(args ...string) {
 		cmd := exec.Command("git", args...)
@@ -36,18 +36,76 @@ func TestDiffAgainst_OnlyAddedLines(t *testing.T) {
 	run("add", "f.txt")
 	run("commit", "-m", "change")
 
-	files, data, err := DiffAgainst(dir, "base")
+	diffs, err := DiffAddedLines(dir, "base")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(files) != 1 {
-		t.Fatalf("expected 1 file in diff, got %d", len(files))
+	if len(diffs) != 1 {
+		t.Fatalf("expected 1 file in diff, got %d", len(diffs))
 	}
-	s := string(data[0])
-	if strings.Contains(s, "B\n") {
+	var texts []string
+	for _, l := range diffs[0].Added {
+		texts = append(texts, l.Text)
+	}
+	s := strings.Join(texts, "\n")
+	if strings.Contains(s, "B") {
 		t.Fatalf("expected removed lines excluded, saw: %q", s)
 	}
-	if !strings.Contains(s, "D\n") {
+	if !strings.Contains(s, "D") {
 		t.Fatalf("expected added line included, payload: %q", s)
 	}
 }
+
+func TestDiffAddedLines_NewFileLineNumbers(t *testing.T) {
+	dir := initRepo(t)
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, string(out))
+		}
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("f.txt", "1\n2\n3\n4\n5\n")
+	write("gone.txt", "x\n")
+	run("add", ".")
+	run("commit", "-m", "base")
+	run("branch", "base")
+
+	// Replace line 2, insert after line 4, and delete another file.
+	write("f.txt", "1\ntwo\n3\n4\nnew\n5\n")
+	write("new file.txt", "a\nb\n")
+	run("rm", "-q", "gone.txt")
+	run("add", ".")
+
+	diffs, err := DiffAddedLines(dir, "base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string][]AddedLine{}
+	for _, d := range diffs {
+		got[d.Path] = d.Added
+	}
+	if _, ok := got["gone.txt"]; ok || len(got) != 2 {
+		t.Fatalf("expected f.txt and new file.txt only, got %+v", got)
+	}
+	want := []AddedLine{{Line: 2, Text: "two"}, {Line: 5, Text: "new"}}
+	if len(got["f.txt"]) != 2 || got["f.txt"][0] != want[0] || got["f.txt"][1] != want[1] {
+		t.Fatalf("unexpected added lines %+v", got["f.txt"])
+	}
+	if a := got["new file.txt"]; len(a) != 2 || a[1] != (AddedLine{Line: 2, Text: "b"}) {
+		t.Fatalf("unexpected added lines for new file %+v", a)
+	}
+}
+
+func TestParseUnifiedDiff_AddedHeaderLikeContent(t *testing.T) {
+	out := "diff --git a/x b/x\nindex 1..2 100644\n--- a/x\n+++ b/x\n@@ -0,0 +1,2 @@\n+++ not a header\n+ok\n\\ No newline at end of file\n"
+	diffs := parseUnifiedDiff([]byte(out))
+	if len(diffs) != 1 || len(diffs[0].Added) != 2 || diffs[0].Added[0] != (AddedLine{Line: 1, Text: "++ not a header"}) {
+		t.Fatalf("unexpected diffs %+v", diffs)
+	}
+}
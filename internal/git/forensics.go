@@ -0,0 +1,237 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// How an object found by ForensicBlobs is reachable.
+const (
+	// ReachStash marks blobs saved in a stash entry (worktree, index or
+	// untracked files).
+	ReachStash = "stash"
+	// ReachReflog marks blobs from commits that only a reflog still
+	// references, e.g. after `reset --hard` or `commit --amend`.
+	ReachReflog = "reflog"
+	// ReachUnreachable marks objects no ref or reflog references, which
+	// survive until `git gc` prunes them.
+	ReachUnreachable = "unreachable"
+)
+
+// LostFoundPrefix is the path reported for unreachable blobs that no commit
+// names; `git fsck --lost-found` writes them there, relative to the git
+// directory.
+const LostFoundPrefix = "lost-found/other/"
+
+// ForensicBlobs returns blobs that exist in the object database but are
+// invisible to ordinary history scans: stash entries, commits only a reflog
+// references, and unreachable objects reported by `git fsck`. Each ref's
+// Reach says which; stash refs also carry their stash@{n} name. Reflog and
+// unreachable refs are limited to blobs no branch, tag or other ref reaches.
+func ForensicBlobs(root string) ([]HistoryBlob, error) {
+	validRoot, err := validateRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	commits, blobs, err := unreferencedObjects(validRoot)
+	if err != nil {
+		return nil, err
+	}
+	hidden := make(map[string]bool, len(blobs))
+	for _, id := range blobs {
+		hidden[id] = true
+	}
+	stash, seen, err := stashBlobs(validRoot)
+	if err != nil {
+		return nil, err
+	}
+	reflog, err := reflogBlobs(validRoot, seen, hidden)
+	if err != nil {
+		return nil, err
+	}
+	var lostCommits []string
+	for _, c := range commits {
+		if !seen[c] {
+			lostCommits = append(lostCommits, c)
+		}
+	}
+	lost, err := unreachableBlobs(validRoot, lostCommits, hidden)
+	if err != nil {
+		return nil, err
+	}
+	merged := mergeBlobs(stash, reflog, lost)
+
+	// Dangling blobs, e.g. from `git add` without a commit, have no path.
+	named := make(map[string]bool, len(merged))
+	for _, b := range merged {
+		named[b.ID] = true
+	}
+	for _, id := range blobs {
+		if !named[id] {
+			merged = append(merged, HistoryBlob{ID: id, Refs: []BlobRef{{Path: LostFoundPrefix + id, Reach: ReachUnreachable}}})
+		}
+	}
+	return merged, nil
+}
+
+// unreferencedObjects lists the commits and blobs that no ref reaches,
+// ignoring reflogs, via `git fsck --unreachable --no-reflogs`. These are the
+// objects `git fsck --lost-found` would recover (plus reflog-only ones),
+// found without writing them into the git directory.
+func unreferencedObjects(validRoot string) (commits, blobs []string, err error) {
+	out, err := exec.Command("git", "-C", validRoot, "fsck", "--unreachable", "--no-reflogs", "--no-progress").Output()
+	if err != nil && len(out) == 0 {
+		return nil, nil, gitError(err)
+	}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		// unreachable <type> <sha>
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 3 || fields[0] != "unreachable" {
+			continue
+		}
+		switch fields[1] {
+		case "commit":
+			commits = append(commits, fields[2])
+		case "blob":
+			blobs = append(blobs, fields[2])
+		}
+	}
+	return commits, blobs, nil
+}
+
+// stashBlobs lists the blobs each stash entry saved and returns the set of
+// commits that make up the stash entries. Entries are read from the
+// refs/stash reflog rather than `git stash list`, which needs a work tree.
+func stashBlobs(validRoot string) ([]HistoryBlob, map[string]bool, error) {
+	commits := map[string]bool{}
+	if exec.Command("git", "-C", validRoot, "rev-parse", "-q", "--verify", "refs/stash").Run() != nil {
+		return nil, commits, nil
+	}
+	out, err := exec.Command("git", "-C", validRoot, "log", "-g", "--format=%H %P", "refs/stash", "--").Output()
+	if err != nil {
+		return nil, nil, gitError(err)
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		// Mirror clones copy refs/stash without its reflog: only the latest
+		// entry is left.
+		out, err = exec.Command("git", "-C", validRoot, "log", "-1", "--format=%H %P", "refs/stash", "--").Output()
+		if err != nil {
+			return nil, nil, gitError(err)
+		}
+	}
+	var raw []byte
+	names := map[string]string{}
+	for n, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		// <stash> <HEAD> <index> [<untracked>]
+		ids := strings.Fields(line)
+		if len(ids) < 3 {
+			continue
+		}
+		w, head, index := ids[0], ids[1], ids[2]
+		names[w] = "stash@{" + strconv.Itoa(n) + "}"
+		commits[w] = true
+		commits[index] = true
+		diffs := [][]string{{head, w}, {head, index}}
+		if len(ids) > 3 {
+			commits[ids[3]] = true
+			diffs = append(diffs, []string{"--root", ids[3]})
+		}
+		for _, d := range diffs {
+			args := append([]string{"-C", validRoot, "diff-tree", "-r", "-z", "--raw", "--no-renames", "--no-abbrev", "--no-commit-id"}, d...)
+			diff, err := exec.Command("git", args...).Output()
+			if err != nil {
+				return nil, nil, gitError(err)
+			}
+			raw = append(raw, "commit "+w+"\x00"...)
+			raw = append(raw, diff...)
+		}
+	}
+	blobs := parseRawLog(raw)
+	for i := range blobs {
+		for j := range blobs[i].Refs {
+			blobs[i].Refs[j].Reach = ReachStash
+			blobs[i].Refs[j].Stash = names[blobs[i].Refs[j].Commit]
+		}
+	}
+	return blobs, commits, nil
+}
+
+// reflogBlobs lists hidden blobs added by commits that are reachable from a
+// reflog but from no ref, skipping the stash commits in seen. Every commit it
+// walks is then added to seen.
+func reflogBlobs(validRoot string, seen, hidden map[string]bool) ([]HistoryBlob, error) {
+	out, err := exec.Command("git", "-C", validRoot, "log", "--reflog", "--not", "--all",
+		"--topo-order", "--reverse", "--no-renames", "--raw", "--no-abbrev", "-m", "-z", "--format=commit %H", "--").Output()
+	if err != nil {
+		return nil, gitError(err)
+	}
+	blobs := hiddenRefs(parseRawLog(out), ReachReflog, seen, hidden)
+	for _, tok := range bytes.Split(out, []byte{0}) {
+		if c, ok := strings.CutPrefix(strings.TrimLeft(string(tok), "\n"), "commit "); ok {
+			seen[c] = true
+		}
+	}
+	return blobs, nil
+}
+
+// unreachableBlobs lists hidden blobs added by commits that no ref or reflog
+// reaches, at their commit and path.
+func unreachableBlobs(validRoot string, commits []string, hidden map[string]bool) ([]HistoryBlob, error) {
+	if len(commits) == 0 {
+		return nil, nil
+	}
+	cmd := exec.Command("git", "-C", validRoot, "log", "--no-walk=unsorted", "--stdin",
+		"--no-renames", "--raw", "--no-abbrev", "-m", "-z", "--format=commit %H", "--")
+	cmd.Stdin = strings.NewReader(strings.Join(commits, "\n") + "\n")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, gitError(err)
+	}
+	return hiddenRefs(parseRawLog(out), ReachUnreachable, nil, hidden), nil
+}
+
+// hiddenRefs keeps the refs to hidden blobs, skipping commits in skip, and
+// labels them with reach.
+func hiddenRefs(blobs []HistoryBlob, reach string, skip, hidden map[string]bool) []HistoryBlob {
+	var out []HistoryBlob
+	for _, b := range blobs {
+		if !hidden[b.ID] {
+			continue
+		}
+		var keep []BlobRef
+		for _, r := range b.Refs {
+			if skip[r.Commit] {
+				continue
+			}
+			r.Reach = reach
+			keep = append(keep, r)
+		}
+		if len(keep) > 0 {
+			out = append(out, HistoryBlob{ID: b.ID, Refs: keep})
+		}
+	}
+	return out
+}
+
+// mergeBlobs combines blob lists, keeping the first position of each blob
+// and every ref.
+func mergeBlobs(lists ...[]HistoryBlob) []HistoryBlob {
+	var out []HistoryBlob
+	index := map[string]int{}
+	for _, list := range lists {
+		for _, b := range list {
+			n, ok := index[b.ID]
+			if !ok {
+				index[b.ID] = len(out)
+				out = append(out, HistoryBlob{ID: b.ID})
+				n = len(out) - 1
+			}
+			out[n].Refs = append(out[n].Refs, b.Refs...)
+		}
+	}
+	return out
+}
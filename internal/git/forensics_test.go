@@ -0,0 +1,120 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestForensicBlobs(t *testing.T) {
+	dir := initRepo(t)
+	run := func(stdin string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Stdin = strings.NewReader(stdin)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, string(out))
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("app.txt", "base\n")
+	run("", "add", ".")
+	run("", "commit", "-q", "-m", "base")
+
+	// A commit undone with reset --hard survives in the reflog.
+	write("reset.txt", "reset secret\n")
+	run("", "add", ".")
+	run("", "commit", "-q", "-m", "oops")
+	undone := run("", "rev-parse", "HEAD")
+	run("", "reset", "-q", "--hard", "HEAD~1")
+
+	// Two stash entries; stash@{1} holds the older one.
+	write("app.txt", "stashed secret one\n")
+	run("", "stash", "-q")
+	write("app.txt", "stashed secret two\n")
+	write("untracked.txt", "untracked secret\n")
+	run("", "stash", "-q", "-u")
+
+	// A commit no ref or reflog names, and a blob that was only ever staged.
+	blob := run("lost commit secret\n", "hash-object", "-w", "--stdin")
+	tree := run("100644 blob "+blob+"\tlost.txt\n", "mktree")
+	lost := run("", "commit-tree", tree, "-m", "lost")
+	dangling := run("staged secret\n", "hash-object", "-w", "--stdin")
+
+	blobs, err := ForensicBlobs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]BlobRef{}
+	ids := map[string]string{}
+	for _, b := range blobs {
+		for _, r := range b.Refs {
+			got[r.Path+"@"+r.Reach+r.Stash] = r
+			ids[r.Path+"@"+r.Reach+r.Stash] = b.ID
+		}
+	}
+	for _, key := range []string{
+		"reset.txt@reflog",
+		"app.txt@stashstash@{0}",
+		"app.txt@stashstash@{1}",
+		"untracked.txt@stashstash@{0}",
+		"lost.txt@unreachable",
+		LostFoundPrefix + dangling + "@unreachable",
+	} {
+		if _, ok := got[key]; !ok {
+			t.Fatalf("missing %s in %+v", key, got)
+		}
+	}
+	if got["reset.txt@reflog"].Commit != undone || got["lost.txt@unreachable"].Commit != lost {
+		t.Fatalf("unexpected commits %+v", got)
+	}
+	for key := range got {
+		if strings.HasPrefix(key, "app.txt@reflog") || strings.HasPrefix(key, "untracked.txt@reflog") {
+			t.Fatalf("stash commits must not be reported as reflog-only: %s", key)
+		}
+	}
+
+	contents := map[string]string{}
+	if err := ReadBlobsWithSkips(dir, []string{ids["app.txt@stashstash@{1}"], ids["lost.txt@unreachable"]}, 0, func(id string, data []byte) error {
+		contents[id] = string(data)
+		return nil
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if contents[ids["app.txt@stashstash@{1}"]] != "stashed secret one\n" || contents[blob] != "lost commit secret\n" {
+		t.Fatalf("unexpected contents %q", contents)
+	}
+
+	// Bare repositories have no work tree: a mirror keeps the latest stash
+	// entry without its reflog, and a plain bare clone has no stash at all.
+	mirror := filepath.Join(t.TempDir(), "mirror.git")
+	if err := MirrorClone(dir, mirror, CloneOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	blobs, err = ForensicBlobs(mirror)
+	if err != nil {
+		t.Fatalf("forensics on a mirror: %v", err)
+	}
+	var stashed bool
+	for _, b := range blobs {
+		for _, r := range b.Refs {
+			stashed = stashed || (r.Path == "untracked.txt" && r.Stash == "stash@{0}")
+		}
+	}
+	if !stashed {
+		t.Fatalf("expected the mirrored stash entry, got %+v", blobs)
+	}
+	bare := filepath.Join(t.TempDir(), "bare.git")
+	run("", "clone", "-q", "--bare", dir, bare)
+	if _, err := ForensicBlobs(bare); err != nil {
+		t.Fatalf("forensics on a bare clone without stashes: %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -119,41 +120,127 @@ func LastNCommits(root string, n int) ([]Entry, error) {
 	return entries, nil
 }
 
-func DiffAgainst(root, base string) ([]string, [][]byte, error) {
+// AddedLine is a line a diff adds, numbered in the new version of the file.
+type AddedLine struct {
+	Line int
+	Text string
+}
+
+// FileDiff lists the lines a diff adds to one file. Deleted files are not
+// reported; files with only removals have no Added lines.
+type FileDiff struct {
+	Path  string
+	Added []AddedLine
+}
+
+// DiffAddedLines diffs base against the working tree with a single
+// `git diff --unified=0` and parses the hunks into the added lines of each
+// file, with their line numbers in the new file.
+func DiffAddedLines(root, base string) ([]FileDiff, error) {
 	validRoot, err := validateRoot(root)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-
-	cmd := exec.Command("git", "-C", validRoot, "diff", "--name-only", base)
+	cmd := exec.Command("git", "-C", validRoot, "-c", "core.quotepath=off", "diff", "--unified=0", "--no-color", "--no-ext-diff", base, "--")
 	out, err := cmd.Output()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	paths := strings.Fields(string(out))
-	var data [][]byte
-	for _, p := range paths {
-		show := exec.Command("git", "-C", validRoot, "diff", "--unified=0", base, "--", p)
-		b, err := show.Output()
-		if err != nil {
-			b = []byte{}
-		}
-		// Extract only added lines from unified diff ('+' lines, excluding headers like '+++' and '@@')
-		buf := bytes.NewBuffer(nil)
-		sc := bufio.NewScanner(bytes.NewReader(b))
-		for sc.Scan() {
-			line := sc.Text()
-			if strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") || strings.HasPrefix(line, "@@") {
+	return parseUnifiedDiff(out), nil
+}
+
+// parseUnifiedDiff parses `git diff --unified=0` output.
+func parseUnifiedDiff(out []byte) []FileDiff {
+	var diffs []FileDiff
+	var cur *FileDiff
+	next := 0       // line number of the next new-file line in the current hunk
+	header := false // between "diff --git" and the first hunk
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Buffer(make([]byte, 64<<10), 16<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			cur, next, header = nil, 0, true
+		case header && strings.HasPrefix(line, "+++ "):
+			name := diffPath(strings.TrimPrefix(line, "+++ "))
+			if name == "" {
+				cur = nil // deleted file
 				continue
 			}
-			if strings.HasPrefix(line, "+") {
-				buf.WriteString(strings.TrimPrefix(line, "+"))
-				buf.WriteByte('\n')
-			}
+			diffs = append(diffs, FileDiff{Path: name})
+			cur = &diffs[len(diffs)-1]
+		case strings.HasPrefix(line, "@@ "):
+			next, header = hunkNewStart(line), false
+		case cur == nil || next == 0:
+		case strings.HasPrefix(line, "+"):
+			cur.Added = append(cur.Added, AddedLine{Line: next, Text: line[1:]})
+			next++
+		case strings.HasPrefix(line, " "):
+			next++
 		}
-		data = append(data, buf.Bytes())
 	}
-	return paths, data, nil
+	return diffs
+}
+
+// CommitDiff lists the lines one commit adds, per file.
+type CommitDiff struct {
+	Hash  string
+	Files []FileDiff
+}
+
+// RangeAddedLines reads the patches of the commits in revRange (e.g.
+// "main..HEAD") that touch paths with a single `git log -p --unified=0`,
+// ancestors first. Merge commits have no patch of their own.
+func RangeAddedLines(root, revRange string, paths []string) ([]CommitDiff, error) {
+	validRoot, err := validateRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"-C", validRoot, "-c", "core.quotepath=off", "log", "--topo-order", "--reverse", "--format=%x00%H", "-p", "--unified=0", "--no-color", "--no-ext-diff", "--no-renames", revRange, "--"}
+	out, err := exec.Command("git", append(args, paths...)...).Output()
+	if err != nil {
+		return nil, err
+	}
+	var commits []CommitDiff
+	// Patches of text files cannot contain NUL, so it only separates commits.
+	for _, rec := range bytes.Split(out, []byte{0}) {
+		hash, patch, _ := bytes.Cut(rec, []byte{'\n'})
+		if len(hash) == 0 {
+			continue
+		}
+		commits = append(commits, CommitDiff{Hash: string(hash), Files: parseUnifiedDiff(patch)})
+	}
+	return commits, nil
+}
+
+// diffPath returns the path from a "+++ b/path" header, or "" for /dev/null.
+func diffPath(s string) string {
+	// git terminates names containing spaces with a tab
+	s = strings.TrimSuffix(s, "\t")
+	if s == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(s, `"`) {
+		if u, err := strconv.Unquote(s); err == nil {
+			s = u
+		}
+	}
+	return strings.TrimPrefix(s, "b/")
+}
+
+// hunkNewStart returns the new-file start line of a "@@ -a,b +c,d @@" header.
+func hunkNewStart(h string) int {
+	fields := strings.Fields(h)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return 0
+	}
+	start, _, _ := strings.Cut(fields[2][1:], ",")
+	n, err := strconv.Atoi(start)
+	if err != nil {
+		return 0
+	}
+	return n
 }
 
 func StagedDiff(root string) ([]string, [][]byte, error) {
@@ -86,7 +86,7 @@ func TestStagedDiff(t *testing.T) {
 	}
 }
 
-func TestDiffAgainst(t *testing.T) {
+func TestDiffAddedLines(t *testing.T) {
 	dir := initRepo(t)
 	write := func(name, content string) {
 		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
@@ -109,11 +109,11 @@ func TestDiffAgainst(t *testing.T) {
 	run("add", "c.txt")
 	run("commit", "-m", "change c")
 
-	files, data, err := DiffAgainst(dir, "base")
+	diffs, err := DiffAddedLines(dir, "base")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(files) == 0 || len(data) == 0 {
+	if len(diffs) == 0 || len(diffs[0].Added) == 0 {
 		t.Fatalf("expected diff against base")
 	}
 }
@@ -0,0 +1,104 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LFSPointer is a parsed Git LFS pointer file.
+type LFSPointer struct {
+	OID  string // SHA-256 of the object, hex encoded
+	Size int64
+}
+
+// lfsPointerMaxSize is the largest pointer file git-lfs will write.
+const lfsPointerMaxSize = 1024
+
+// ParseLFSPointer reports whether data is a Git LFS pointer file and, if so,
+// returns the object it points to.
+func ParseLFSPointer(data []byte) (LFSPointer, bool) {
+	var p LFSPointer
+	if len(data) > lfsPointerMaxSize {
+		return p, false
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	first := true
+	for sc.Scan() {
+		key, value, ok := strings.Cut(sc.Text(), " ")
+		if !ok {
+			return p, false
+		}
+		if first {
+			if key != "version" || (value != "https://git-lfs.github.com/spec/v1" && value != "https://hawser.github.com/spec/v1") {
+				return p, false
+			}
+			first = false
+			continue
+		}
+		switch key {
+		case "oid":
+			oid, ok := strings.CutPrefix(value, "sha256:")
+			if !ok || !isHex(oid, 64) {
+				return p, false
+			}
+			p.OID = oid
+		case "size":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < 0 {
+				return p, false
+			}
+			p.Size = n
+		}
+	}
+	return p, p.OID != ""
+}
+
+// ObjectPath returns where the pointer's object is kept in the LFS object
+// store dir, as returned by LFSObjectsDir.
+func (p LFSPointer) ObjectPath(dir string) string {
+	return filepath.Join(dir, p.OID[0:2], p.OID[2:4], p.OID)
+}
+
+// LFSObjectsDir returns the local Git LFS object store of the repository at
+// root: .git/lfs/objects, or objects under lfs.storage when configured.
+func LFSObjectsDir(root string) (string, error) {
+	validRoot, err := validateRoot(root)
+	if err != nil {
+		return "", err
+	}
+	out, err := exec.Command("git", "-C", validRoot, "rev-parse", "--git-common-dir").Output()
+	if err != nil {
+		return "", gitError(err)
+	}
+	gitDir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(validRoot, gitDir)
+	}
+	storage := filepath.Join(gitDir, "lfs")
+	// lfs.storage is relative to the git directory unless absolute.
+	if s, err := exec.Command("git", "-C", validRoot, "config", "--get", "lfs.storage").Output(); err == nil {
+		if v := strings.TrimSpace(string(s)); v != "" {
+			storage = v
+			if !filepath.IsAbs(storage) {
+				storage = filepath.Join(gitDir, storage)
+			}
+		}
+	}
+	return filepath.Join(storage, "objects"), nil
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
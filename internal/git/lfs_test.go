@@ -0,0 +1,49 @@
+package git
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLFSPointer(t *testing.T) {
+	oid := strings.Repeat("0a", 32)
+	p, ok := ParseLFSPointer([]byte("version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 12345\n"))
+	if !ok || p.OID != oid || p.Size != 12345 {
+		t.Fatalf("unexpected pointer %+v ok=%v", p, ok)
+	}
+	if got := p.ObjectPath("/store"); got != filepath.Join("/store", "0a", "0a", oid) {
+		t.Fatalf("ObjectPath=%s", got)
+	}
+	for _, bad := range []string{
+		"hello world\n",
+		"oid sha256:" + oid + "\nversion https://git-lfs.github.com/spec/v1\n",
+		"version https://git-lfs.github.com/spec/v1\noid sha256:XYZ\nsize 1\n",
+		"version https://git-lfs.github.com/spec/v1\noid md5:" + oid + "\nsize 1\n",
+		"version https://git-lfs.github.com/spec/v1\nsize 1\n",
+	} {
+		if _, ok := ParseLFSPointer([]byte(bad)); ok {
+			t.Errorf("expected %q not to parse as a pointer", bad)
+		}
+	}
+}
+
+func TestLFSObjectsDir(t *testing.T) {
+	dir := initRepo(t)
+	got, err := LFSObjectsDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, ".git", "lfs", "objects"); got != want {
+		t.Fatalf("LFSObjectsDir=%s want %s", got, want)
+	}
+	cmd := exec.Command("git", "config", "lfs.storage", "lfs-store")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	if got, _ := LFSObjectsDir(dir); got != filepath.Join(dir, ".git", "lfs-store", "objects") {
+		t.Fatalf("expected lfs.storage to be honoured, got %s", got)
+	}
+}
@@ -0,0 +1,111 @@
+package git
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+)
+
+// Message is commit metadata text to scan: a commit message, an annotated
+// tag's message or a git note. Path is a virtual path such as
+// commit:<sha>::message, tag:<name>::message or commit:<sha>::notes/commits.
+type Message struct {
+	Path   string
+	Commit string // the commit the text belongs to or annotates
+	Text   []byte
+}
+
+// IsMessagePath reports whether p is a virtual path produced for commit
+// metadata rather than a file.
+func IsMessagePath(p string) bool {
+	return (strings.HasPrefix(p, "commit:") || strings.HasPrefix(p, "tag:")) && strings.Contains(p, "::")
+}
+
+// CommitMessages returns the full message of every commit in sel, oldest
+// first.
+func CommitMessages(root string, sel RevSelection) ([]Message, error) {
+	validRoot, err := validateRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	revs, err := sel.logArgs()
+	if err != nil {
+		return nil, err
+	}
+	args := append([]string{"-C", validRoot, "log"}, revs...)
+	args = append(args, "--topo-order", "--reverse", "-z", "--format=%H%n%B", "--")
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, gitError(err)
+	}
+	var msgs []Message
+	for _, rec := range bytes.Split(out, []byte{0}) {
+		hash, body, ok := bytes.Cut(rec, []byte{'\n'})
+		if !ok {
+			continue
+		}
+		h := string(hash)
+		msgs = append(msgs, Message{Path: "commit:" + h + "::message", Commit: h, Text: body})
+	}
+	return msgs, nil
+}
+
+// TagMessages returns the message of every annotated tag, including any
+// signature, with Commit set to the commit the tag points at.
+func TagMessages(root string) ([]Message, error) {
+	validRoot, err := validateRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	// Four NUL-terminated fields per tag; for-each-ref adds a newline after
+	// each record.
+	out, err := exec.Command("git", "-C", validRoot, "for-each-ref", "refs/tags",
+		"--format=%(objecttype)%00%(refname:strip=2)%00%(*objectname)%00%(contents)%00").Output()
+	if err != nil {
+		return nil, gitError(err)
+	}
+	fields := strings.Split(string(out), "\x00")
+	var msgs []Message
+	for i := 0; i+3 < len(fields); i += 4 {
+		if strings.TrimLeft(fields[i], "\n") != "tag" {
+			continue
+		}
+		msgs = append(msgs, Message{Path: "tag:" + fields[i+1] + "::message", Commit: fields[i+2], Text: []byte(fields[i+3])})
+	}
+	return msgs, nil
+}
+
+// Notes returns every git note under refs/notes/. Notes on commits get the
+// path commit:<sha>::notes/<ref>, e.g. commit:abc123::notes/commits.
+func Notes(root string) ([]Message, error) {
+	validRoot, err := validateRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	out, err := exec.Command("git", "-C", validRoot, "for-each-ref", "refs/notes", "--format=%(refname)").Output()
+	if err != nil {
+		return nil, gitError(err)
+	}
+	var msgs []Message
+	var ids []string
+	for _, ref := range strings.Fields(string(out)) {
+		list, err := exec.Command("git", "-C", validRoot, "notes", "--ref="+ref, "list").Output()
+		if err != nil {
+			return nil, gitError(err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(list)), "\n") {
+			// <note blob> <annotated object>
+			note, object, ok := strings.Cut(line, " ")
+			if !ok {
+				continue
+			}
+			msgs = append(msgs, Message{Path: "commit:" + object + "::" + strings.TrimPrefix(ref, "refs/"), Commit: object})
+			ids = append(ids, note)
+		}
+	}
+	err = catFileBatch(validRoot, ids, 0, func(i int, data []byte) error {
+		msgs[i].Text = data
+		return nil
+	}, nil)
+	return msgs, err
+}
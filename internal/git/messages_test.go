@@ -0,0 +1,65 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommitMessagesTagsAndNotes(t *testing.T) {
+	dir := initRepo(t)
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, string(out))
+		}
+		return strings.TrimSpace(string(out))
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", ".")
+	run("commit", "-q", "-m", "first", "-m", "body line")
+	first := run("rev-parse", "HEAD")
+	run("commit", "-q", "--allow-empty", "-m", "second")
+	second := run("rev-parse", "HEAD")
+	run("tag", "-a", "v1", "-m", "release notes", first)
+	run("tag", "light", second)
+	run("notes", "add", "-m", "note text", second)
+	run("notes", "--ref=review", "add", "-m", "review text", first)
+
+	msgs, err := CommitMessages(dir, RevSelection{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 || msgs[0].Path != "commit:"+first+"::message" || string(msgs[0].Text) != "first\n\nbody line\n" || msgs[1].Commit != second {
+		t.Fatalf("unexpected commit messages %+v", msgs)
+	}
+
+	tags, err := TagMessages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 1 || tags[0].Path != "tag:v1::message" || tags[0].Commit != first || string(tags[0].Text) != "release notes\n" {
+		t.Fatalf("expected only the annotated tag, got %+v", tags)
+	}
+
+	notes, err := Notes(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, n := range notes {
+		got[n.Path] = string(n.Text)
+	}
+	if len(got) != 2 || got["commit:"+second+"::notes/commits"] != "note text\n" || got["commit:"+first+"::notes/review"] != "review text\n" {
+		t.Fatalf("unexpected notes %q", got)
+	}
+	if !IsMessagePath(tags[0].Path) || IsMessagePath("image.tar::etc/passwd") || IsMessagePath("commit.txt") {
+		t.Fatal("IsMessagePath misclassified a path")
+	}
+}
@@ -0,0 +1,134 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// RefUpdate is one `<old> <new> <ref>` line that git passes to pre-receive
+// and post-receive hooks on stdin.
+type RefUpdate struct {
+	Old string
+	New string
+	Ref string
+}
+
+// Created reports whether the update creates the ref.
+func (u RefUpdate) Created() bool { return IsZeroID(u.Old) }
+
+// Deleted reports whether the update deletes the ref.
+func (u RefUpdate) Deleted() bool { return IsZeroID(u.New) }
+
+// IsZeroID reports whether id is git's all-zero object ID, which stands for
+// a missing ref in hook input.
+func IsZeroID(id string) bool {
+	return id != "" && strings.Trim(id, "0") == ""
+}
+
+// ParseRefUpdates reads pre-receive hook input.
+func ParseRefUpdates(r io.Reader) ([]RefUpdate, error) {
+	var updates []RefUpdate
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("malformed ref update %q", line)
+		}
+		updates = append(updates, RefUpdate{Old: fields[0], New: fields[1], Ref: fields[2]})
+	}
+	return updates, sc.Err()
+}
+
+// TagMessage returns the message of the annotated tag object id. The bool
+// is false when id is not a tag object (e.g. a lightweight tag's commit).
+func TagMessage(root, id string) (Message, bool, error) {
+	validRoot, err := validateRoot(root)
+	if err != nil {
+		return Message{}, false, err
+	}
+	if err := validateRev(id); err != nil {
+		return Message{}, false, err
+	}
+	typ, err := exec.Command("git", "-C", validRoot, "cat-file", "-t", id).Output()
+	if err != nil {
+		return Message{}, false, gitError(err)
+	}
+	if strings.TrimSpace(string(typ)) != "tag" {
+		return Message{}, false, nil
+	}
+	out, err := exec.Command("git", "-C", validRoot, "cat-file", "tag", id).Output()
+	if err != nil {
+		return Message{}, false, gitError(err)
+	}
+	// object <sha>, type <type>, tag <name>, tagger ..., blank line, message
+	header, body, _ := bytes.Cut(out, []byte("\n\n"))
+	var m Message
+	m.Text = body
+	for _, line := range strings.Split(string(header), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "object":
+			m.Commit = value
+		case "tag":
+			m.Path = "tag:" + value + "::message"
+		}
+	}
+	return m, true, nil
+}
+
+// ParsePrePushInput reads pre-push hook input, `<local ref> <local sha>
+// <remote ref> <remote sha>` per line, as updates of the remote ref from the
+// remote's object to the local one.
+func ParsePrePushInput(r io.Reader) ([]RefUpdate, error) {
+	var updates []RefUpdate
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("malformed pre-push line %q", line)
+		}
+		updates = append(updates, RefUpdate{Old: fields[3], New: fields[1], Ref: fields[2]})
+	}
+	return updates, sc.Err()
+}
+
+// HasCommit reports whether id names a commit present in the repository.
+// A pre-push hook may see remote objects that were never fetched.
+func HasCommit(root, id string) bool {
+	validRoot, err := validateRoot(root)
+	if err != nil || validateRev(id) != nil {
+		return false
+	}
+	return exec.Command("git", "-C", validRoot, "cat-file", "-e", id+"^{commit}").Run() == nil
+}
+
+// HooksDir returns the directory git runs hooks from, honouring
+// core.hooksPath and linked worktrees.
+func HooksDir(root string) (string, error) {
+	validRoot, err := validateRoot(root)
+	if err != nil {
+		return "", err
+	}
+	out, err := exec.Command("git", "-C", validRoot, "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return "", gitError(err)
+	}
+	dir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(validRoot, dir)
+	}
+	return dir, nil
+}
@@ -0,0 +1,51 @@
+package git
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRefUpdates(t *testing.T) {
+	zero := strings.Repeat("0", 40)
+	in := zero + " abc refs/heads/new\n\nabc " + zero + " refs/heads/gone\nabc def refs/heads/main\n"
+	updates, err := ParseRefUpdates(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 3 || !updates[0].Created() || !updates[1].Deleted() || updates[2].Created() || updates[2].Deleted() || updates[2].Ref != "refs/heads/main" {
+		t.Fatalf("unexpected updates %+v", updates)
+	}
+	if _, err := ParseRefUpdates(strings.NewReader("abc refs/heads/main\n")); err == nil {
+		t.Fatal("expected malformed line to fail")
+	}
+}
+
+func TestParsePrePushInputAndHooksDir(t *testing.T) {
+	zero := strings.Repeat("0", 40)
+	updates, err := ParsePrePushInput(strings.NewReader("refs/heads/main abc refs/heads/main def\nrefs/heads/x " + zero + " refs/heads/x abc\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 2 || updates[0] != (RefUpdate{Old: "def", New: "abc", Ref: "refs/heads/main"}) || !updates[1].Deleted() {
+		t.Fatalf("unexpected updates %+v", updates)
+	}
+
+	dir := initRepo(t)
+	got, err := HooksDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, ".git", "hooks"); got != want {
+		t.Fatalf("HooksDir=%s want %s", got, want)
+	}
+	cmd := exec.Command("git", "config", "core.hooksPath", ".githooks")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	if got, _ := HooksDir(dir); got != filepath.Join(dir, ".githooks") {
+		t.Fatalf("expected core.hooksPath to be honoured, got %s", got)
+	}
+}
@@ -0,0 +1,181 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// RevSelection chooses the commits a history scan walks. The zero value
+// selects every commit reachable from HEAD.
+type RevSelection struct {
+	All      bool     // every local branch, tag and remote-tracking branch
+	Range    string   // revision range, e.g. v1.0..v2.0 or main...feature
+	Branches []string // branch names or glob patterns, e.g. release/*
+	Since    string   // only commits more recent than this date
+	Until    string   // only commits older than this date
+	Author   string   // only commits whose author matches this pattern
+	MaxCount int      // only the most recent N selected commits (0=all)
+
+	// Revs are further commits to start from, e.g. the tips of a push.
+	Revs []string
+	// ExcludeExisting drops commits reachable from any existing ref, so
+	// only objects a push introduces are selected.
+	ExcludeExisting bool
+	// Exclude drops commits reachable from these revisions.
+	Exclude []string
+	// ExcludeRemote drops commits reachable from this remote's
+	// remote-tracking branches.
+	ExcludeRemote string
+}
+
+// logArgs returns the revision and filter arguments for git log.
+func (s RevSelection) logArgs() ([]string, error) {
+	var revs []string
+	if s.All {
+		revs = append(revs, historyRefs...)
+	}
+	if s.Range != "" {
+		if err := validateRev(s.Range); err != nil {
+			return nil, err
+		}
+		revs = append(revs, s.Range)
+	}
+	for _, b := range s.Branches {
+		if err := validateRev(b); err != nil {
+			return nil, err
+		}
+		// --branches implies a trailing /* for plain names, so only use it
+		// for patterns and pass plain names through as revisions.
+		if strings.ContainsAny(b, "*?[") {
+			revs = append(revs, "--branches="+b)
+		} else {
+			revs = append(revs, b)
+		}
+	}
+	for _, r := range s.Revs {
+		if err := validateRev(r); err != nil {
+			return nil, err
+		}
+		revs = append(revs, r)
+	}
+	if len(revs) == 0 {
+		revs = []string{"HEAD"}
+	}
+	if s.ExcludeExisting || len(s.Exclude) > 0 || s.ExcludeRemote != "" {
+		revs = append(revs, "--not")
+	}
+	if s.ExcludeExisting {
+		revs = append(revs, "--all")
+	}
+	for _, r := range s.Exclude {
+		if err := validateRev(r); err != nil {
+			return nil, err
+		}
+		revs = append(revs, r)
+	}
+	if s.ExcludeRemote != "" {
+		if err := validateRev(s.ExcludeRemote); err != nil {
+			return nil, err
+		}
+		revs = append(revs, "--remotes="+s.ExcludeRemote)
+	}
+	for _, f := range []struct{ name, value string }{{"since", s.Since}, {"until", s.Until}, {"author", s.Author}} {
+		if f.value != "" {
+			revs = append(revs, "--"+f.name+"="+f.value)
+		}
+	}
+	if s.MaxCount > 0 {
+		revs = append(revs, fmt.Sprintf("--max-count=%d", s.MaxCount))
+	}
+	return revs, nil
+}
+
+// validateRev rejects revisions that git would parse as options.
+func validateRev(rev string) error {
+	if rev == "" || strings.HasPrefix(rev, "-") || strings.ContainsAny(rev, "\x00\n") {
+		return fmt.Errorf("invalid revision %q", rev)
+	}
+	return nil
+}
+
+// HistoryBlobs returns the unique blobs added or modified by the commits in
+// sel, oldest first. Each blob lists every commit/path that introduced it, so
+// content is read and scanned once however many branches, merges or files
+// carry it. Deletions, submodules and symlinks are skipped.
+func HistoryBlobs(root string, sel RevSelection) ([]HistoryBlob, error) {
+	validRoot, err := validateRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	revs, err := sel.logArgs()
+	if err != nil {
+		return nil, err
+	}
+	args := append([]string{"-C", validRoot, "log"}, revs...)
+	args = append(args, "--topo-order", "--reverse", "--no-renames", "--raw", "--no-abbrev", "-m", "-z", "--format=commit %H", "--")
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, gitError(err)
+	}
+	return parseRawLog(out), nil
+}
+
+// TreeBlobs lists every file in the tree of treeish (a commit, tag or tree)
+// without checking it out, so it also works in bare repositories. Blobs that
+// appear under several paths are returned once with a ref per path; the refs
+// carry the commit treeish resolves to, if any. Submodules and symlinks are
+// skipped.
+func TreeBlobs(root, treeish string) ([]HistoryBlob, error) {
+	validRoot, err := validateRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRev(treeish); err != nil {
+		return nil, err
+	}
+	out, err := exec.Command("git", "-C", validRoot, "ls-tree", "-r", "-z", "--full-tree", treeish).Output()
+	if err != nil {
+		return nil, gitError(err)
+	}
+	commit := ""
+	if c, err := exec.Command("git", "-C", validRoot, "rev-parse", "--verify", "--quiet", treeish+"^{commit}").Output(); err == nil {
+		commit = strings.TrimSpace(string(c))
+	}
+	return parseLsTree(out, commit), nil
+}
+
+// parseLsTree parses `git ls-tree -r -z` output.
+func parseLsTree(out []byte, commit string) []HistoryBlob {
+	var blobs []HistoryBlob
+	index := map[string]int{}
+	for _, entry := range bytes.Split(out, []byte{0}) {
+		// <mode> SP <type> SP <object> TAB <path>
+		meta, path, ok := strings.Cut(string(entry), "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 3 || fields[1] != "blob" || fields[0] == "120000" {
+			continue
+		}
+		id := fields[2]
+		n, ok := index[id]
+		if !ok {
+			n = len(blobs)
+			index[id] = n
+			blobs = append(blobs, HistoryBlob{ID: id})
+		}
+		blobs[n].Refs = append(blobs[n].Refs, BlobRef{Commit: commit, Path: path})
+	}
+	return blobs
+}
+
+// gitError adds git's stderr to a failed command's error.
+func gitError(err error) error {
+	if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(ee.Stderr)))
+	}
+	return err
+}
@@ -0,0 +1,120 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRevSelectionLogArgs(t *testing.T) {
+	args, err := RevSelection{}.logArgs()
+	if err != nil || !reflect.DeepEqual(args, []string{"HEAD"}) {
+		t.Fatalf("expected HEAD by default, got %v %v", args, err)
+	}
+	args, err = RevSelection{Range: "v1..v2", Branches: []string{"main", "release/*"}, Since: "2024-01-01", Author: "alice", MaxCount: 5}.logArgs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"v1..v2", "main", "--branches=release/*", "--since=2024-01-01", "--author=alice", "--max-count=5"}
+	if !reflect.DeepEqual(args, want) {
+		t.Fatalf("got %v want %v", args, want)
+	}
+	for _, sel := range []RevSelection{{Range: "--output=/tmp/x"}, {Branches: []string{"-p"}}} {
+		if _, err := sel.logArgs(); err == nil {
+			t.Fatalf("expected option-like revision to be rejected: %+v", sel)
+		}
+	}
+}
+
+func TestHistoryBlobsAndTreeBlobs(t *testing.T) {
+	dir := initRepo(t)
+	run := func(env []string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), env...)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, string(out))
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit := func(date, author, msg string) {
+		env := []string{"GIT_AUTHOR_DATE=" + date, "GIT_COMMITTER_DATE=" + date, "GIT_AUTHOR_NAME=" + author}
+		run(env, "add", ".")
+		run(env, "commit", "-q", "-m", msg)
+	}
+	write("a.txt", "one\n")
+	commit("2023-01-01T00:00:00Z", "alice", "first")
+	run(nil, "tag", "v1")
+	write("b.txt", "two\n")
+	write("c.txt", "two\n")
+	commit("2024-06-01T00:00:00Z", "bob", "second")
+	run(nil, "tag", "v2")
+	write("a.txt", "three\n")
+	commit("2024-07-01T00:00:00Z", "alice", "third")
+
+	paths := func(blobs []HistoryBlob) []string {
+		var ps []string
+		for _, b := range blobs {
+			for _, r := range b.Refs {
+				ps = append(ps, r.Path)
+			}
+		}
+		return ps
+	}
+	cases := []struct {
+		sel  RevSelection
+		want []string
+	}{
+		{RevSelection{Range: "v1..v2"}, []string{"b.txt", "c.txt"}},
+		{RevSelection{Since: "2024-01-01"}, []string{"b.txt", "c.txt", "a.txt"}},
+		{RevSelection{Until: "2024-01-01"}, []string{"a.txt"}},
+		{RevSelection{Author: "alice"}, []string{"a.txt", "a.txt"}},
+		{RevSelection{MaxCount: 1}, []string{"a.txt"}},
+	}
+	for _, c := range cases {
+		blobs, err := HistoryBlobs(dir, c.sel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := paths(blobs); !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("%+v: got %v want %v", c.sel, got, c.want)
+		}
+	}
+
+	// Tree listing works against a bare clone without a checkout.
+	bare := filepath.Join(t.TempDir(), "bare.git")
+	run(nil, "clone", "-q", "--bare", dir, bare)
+	blobs, err := TreeBlobs(bare, "v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blobs) != 2 || !reflect.DeepEqual(paths(blobs), []string{"a.txt", "b.txt", "c.txt"}) {
+		t.Fatalf("unexpected tree blobs %+v", blobs)
+	}
+	v2 := run(nil, "rev-parse", "v2^{commit}")
+	if blobs[0].Refs[0].Commit != v2 {
+		t.Fatalf("expected refs at %s, got %+v", v2, blobs[0].Refs)
+	}
+	got := map[string]string{}
+	if err := ReadBlobsWithSkips(bare, []string{blobs[0].ID}, 0, func(id string, data []byte) error {
+		got[id] = string(data)
+		return nil
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got[blobs[0].ID] != "one\n" {
+		t.Fatalf("expected v2 content of a.txt, got %q", got)
+	}
+	if _, err := TreeBlobs(bare, "--help"); err == nil {
+		t.Fatal("expected option-like ref to be rejected")
+	}
+}
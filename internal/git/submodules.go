@@ -0,0 +1,54 @@
+package git
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Submodule is a submodule recorded in the superproject's index.
+type Submodule struct {
+	Path     string // slash-separated, relative to the superproject root
+	Recorded string // commit the superproject records for the submodule
+	Head     string // commit checked out in the submodule; empty if not initialised
+}
+
+// Initialized reports whether the submodule has been checked out.
+func (s Submodule) Initialized() bool { return s.Head != "" }
+
+// Submodules lists the submodules (gitlinks) in the index of the repository
+// at root, with the commit each initialised one has checked out.
+func Submodules(root string) ([]Submodule, error) {
+	validRoot, err := validateRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	out, err := exec.Command("git", "-C", validRoot, "ls-files", "--stage", "-z").Output()
+	if err != nil {
+		return nil, gitError(err)
+	}
+	var subs []Submodule
+	for _, entry := range bytes.Split(out, []byte{0}) {
+		// <mode> SP <object> SP <stage> TAB <path>
+		meta, path, ok := strings.Cut(string(entry), "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 3 || fields[0] != "160000" {
+			continue
+		}
+		s := Submodule{Path: path, Recorded: fields[1]}
+		dir := filepath.Join(validRoot, filepath.FromSlash(path))
+		// Without its own .git, rev-parse would answer for the superproject.
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			if head, err := exec.Command("git", "-C", dir, "rev-parse", "--verify", "--quiet", "HEAD").Output(); err == nil {
+				s.Head = strings.TrimSpace(string(head))
+			}
+		}
+		subs = append(subs, s)
+	}
+	return subs, nil
+}
@@ -0,0 +1,3 @@
+// Package hooks installs, removes and inspects the git hook scripts that run
+// Redactyl, chaining to any hook that was already in place.
+package hooks
@@ -0,0 +1,129 @@
+package hooks
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Supported lists the hooks redactyl can manage.
+var Supported = []string{"pre-commit", "pre-push"}
+
+// marker identifies hook scripts written by redactyl.
+const marker = "# managed by redactyl"
+
+// ChainedSuffix is appended to an existing hook's name when redactyl is
+// installed in its place; the redactyl script runs it first.
+const ChainedSuffix = ".redactyl-chained"
+
+var scripts = map[string]string{
+	"pre-commit": `#!/bin/sh
+` + marker + `: pre-commit
+# A hook that was here before is kept as pre-commit` + ChainedSuffix + ` and runs first.
+chained="$(dirname "$0")/pre-commit` + ChainedSuffix + `"
+if [ -x "$chained" ]; then
+	"$chained" "$@" || exit $?
+fi
+exec redactyl scan --staged --no-tui
+`,
+	"pre-push": `#!/bin/sh
+` + marker + `: pre-push
+# A hook that was here before is kept as pre-push` + ChainedSuffix + ` and runs first.
+chained="$(dirname "$0")/pre-push` + ChainedSuffix + `"
+input=$(cat)
+if [ -x "$chained" ]; then
+	printf '%s\n' "$input" | "$chained" "$@" || exit $?
+fi
+printf '%s\n' "$input" | redactyl hook pre-push "$@"
+`,
+}
+
+// ErrNotManaged is returned when uninstalling a hook redactyl did not write.
+var ErrNotManaged = errors.New("hook was not installed by redactyl")
+
+// State describes one hook in a hooks directory.
+type State struct {
+	Name      string
+	Path      string
+	Exists    bool   // a hook file is present
+	Managed   bool   // the hook file was written by redactyl
+	Chained   string // path of the chained original hook, if present
+	Installed bool   // set by Install when it wrote the script
+	Restored  bool   // set by Uninstall when it put the original hook back
+}
+
+// Status inspects hook name in dir.
+func Status(dir, name string) (State, error) {
+	if _, ok := scripts[name]; !ok {
+		return State{}, fmt.Errorf("unsupported hook %q (supported: pre-commit, pre-push)", name)
+	}
+	st := State{Name: name, Path: filepath.Join(dir, name)}
+	if data, err := os.ReadFile(st.Path); err == nil {
+		st.Exists = true
+		st.Managed = bytes.Contains(data, []byte(marker))
+	} else if !os.IsNotExist(err) {
+		return st, err
+	}
+	if _, err := os.Stat(st.Path + ChainedSuffix); err == nil {
+		st.Chained = st.Path + ChainedSuffix
+	}
+	return st, nil
+}
+
+// Install writes the redactyl script for hook name into dir, creating dir
+// if needed. An existing hook that redactyl did not write is renamed with
+// ChainedSuffix and run before the scan; reinstalling refreshes the script.
+func Install(dir, name string) (State, error) {
+	st, err := Status(dir, name)
+	if err != nil {
+		return st, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return st, err
+	}
+	if st.Exists && !st.Managed {
+		if st.Chained != "" {
+			return st, fmt.Errorf("%s exists and %s is already taken; remove one of them first", st.Path, st.Chained)
+		}
+		if err := os.Rename(st.Path, st.Path+ChainedSuffix); err != nil {
+			return st, err
+		}
+		st.Chained = st.Path + ChainedSuffix
+	}
+	if err := os.WriteFile(st.Path, []byte(scripts[name]), 0755); err != nil {
+		return st, err
+	}
+	// WriteFile keeps the mode of an existing file.
+	if err := os.Chmod(st.Path, 0755); err != nil {
+		return st, err
+	}
+	st.Exists, st.Managed, st.Installed = true, true, true
+	return st, nil
+}
+
+// Uninstall removes the redactyl script for hook name from dir and restores
+// the chained original hook, if any. Removing a hook that is not installed
+// is a no-op; a hook redactyl did not write is left alone with
+// ErrNotManaged.
+func Uninstall(dir, name string) (State, error) {
+	st, err := Status(dir, name)
+	if err != nil || !st.Exists {
+		return st, err
+	}
+	if !st.Managed {
+		return st, fmt.Errorf("%s: %w", st.Path, ErrNotManaged)
+	}
+	if err := os.Remove(st.Path); err != nil {
+		return st, err
+	}
+	st.Exists, st.Managed = false, false
+	if st.Chained != "" {
+		if err := os.Rename(st.Chained, st.Path); err != nil {
+			return st, err
+		}
+		st.Exists, st.Chained, st.Restored = true, "", true
+	}
+	return st, nil
+}
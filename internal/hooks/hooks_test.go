@@ -0,0 +1,86 @@
+package hooks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallChainsAndUninstallRestores(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "hooks")
+
+	st, err := Install(dir, "pre-commit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Installed || st.Chained != "" {
+		t.Fatalf("unexpected state %+v", st)
+	}
+	if info, err := os.Stat(st.Path); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Fatalf("expected executable hook, got %v %v", info, err)
+	}
+
+	// An existing foreign hook is kept and chained, not clobbered.
+	original := "#!/bin/sh\necho lint\n"
+	prePush := filepath.Join(dir, "pre-push")
+	if err := os.WriteFile(prePush, []byte(original), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if st, _ := Status(dir, "pre-push"); !st.Exists || st.Managed {
+		t.Fatalf("expected foreign hook, got %+v", st)
+	}
+	st, err = Install(dir, "pre-push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Chained != prePush+ChainedSuffix {
+		t.Fatalf("expected chained hook, got %+v", st)
+	}
+	data, _ := os.ReadFile(prePush)
+	if !strings.Contains(string(data), "redactyl hook pre-push") || !strings.Contains(string(data), "pre-push"+ChainedSuffix) {
+		t.Fatalf("unexpected script:\n%s", data)
+	}
+
+	// Reinstalling refreshes the script and keeps the chain.
+	if st, err = Install(dir, "pre-push"); err != nil || st.Chained == "" {
+		t.Fatalf("reinstall: %+v %v", st, err)
+	}
+
+	st, err = Uninstall(dir, "pre-push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Restored {
+		t.Fatalf("expected original to be restored, got %+v", st)
+	}
+	if data, _ := os.ReadFile(prePush); string(data) != original {
+		t.Fatalf("original hook not restored: %q", data)
+	}
+	if _, err := Uninstall(dir, "pre-push"); !errors.Is(err, ErrNotManaged) {
+		t.Fatalf("expected ErrNotManaged for a foreign hook, got %v", err)
+	}
+
+	if _, err := Uninstall(dir, "pre-commit"); err != nil {
+		t.Fatal(err)
+	}
+	if st, _ := Status(dir, "pre-commit"); st.Exists {
+		t.Fatalf("expected pre-commit removed, got %+v", st)
+	}
+	if _, err := Install(dir, "post-merge"); err == nil {
+		t.Fatal("expected unsupported hook to fail")
+	}
+}
+
+func TestInstallRefusesTakenChainSlot(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"pre-commit", "pre-commit" + ChainedSuffix} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := Install(dir, "pre-commit"); err == nil {
+		t.Fatal("expected install to refuse overwriting the chained hook")
+	}
+}
@@ -10,13 +10,18 @@ import (
 type Matcher struct{ ps []gitignore.Pattern }
 
 func Load(path string) (Matcher, error) {
-	var m Matcher
 	// load .redactylignore if present; fallback to none
 	// simple: use gitignore parser; non-existent is fine
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return m, nil
+		return Matcher{}, nil
 	}
+	return Parse(data), nil
+}
+
+// Parse builds a matcher from .redactylignore contents, e.g. a copy read
+// from a git tree rather than the working tree.
+func Parse(data []byte) Matcher {
 	var ps []gitignore.Pattern
 	for _, line := range strings.Split(string(data), "\n") {
 		line = strings.TrimSpace(line)
@@ -25,8 +30,7 @@ func Load(path string) (Matcher, error) {
 		}
 		ps = append(ps, gitignore.ParsePattern(line, nil))
 	}
-	m.ps = ps
-	return m, nil
+	return Matcher{ps: ps}
 }
 
 func (m Matcher) Match(p string) bool {
@@ -11,6 +11,37 @@ import (
 
 type Baseline struct {
 	Items map[string]bool `json:"items"`
+	// Entries records where each key was found, without the secret, so
+	// reports can still locate entries that no longer match.
+	Entries map[string]BaselineEntry `json:"entries,omitempty"`
+}
+
+// BaselineEntry is the location of a baselined finding.
+type BaselineEntry struct {
+	Path     string `json:"path"`
+	Detector string `json:"detector"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// Add baselines f, recording its location.
+func (b *Baseline) Add(f types.Finding) {
+	if b.Items == nil {
+		b.Items = map[string]bool{}
+	}
+	if b.Entries == nil {
+		b.Entries = map[string]BaselineEntry{}
+	}
+	key := FindingKey(f)
+	b.Items[key] = true
+	b.Entries[key] = BaselineEntry{Path: f.Path, Detector: f.Detector, Line: f.Line}
+}
+
+// Remove drops f from the baseline under both key formats.
+func (b *Baseline) Remove(f types.Finding) {
+	key := FindingKey(f)
+	delete(b.Items, key)
+	delete(b.Items, LegacyFindingKey(f))
+	delete(b.Entries, key)
 }
 
 const FingerprintMetadataKey = "redactyl_fingerprint"
@@ -30,7 +61,7 @@ func LoadBaseline(path string) (Baseline, error) {
 func SaveBaseline(path string, findings []types.Finding) error {
 	b := Baseline{Items: map[string]bool{}}
 	for _, f := range findings {
-		b.Items[FindingKey(f)] = true
+		b.Add(f)
 	}
 	buf, err := json.MarshalIndent(b, "", "  ")
 	if err != nil {
@@ -84,14 +115,24 @@ func fingerprintValue(path, detector, match string) string {
 	return hex.EncodeToString(sum[:])
 }
 
-func ShouldFail(findings []types.Finding, failOn string) bool {
-	level := map[string]int{"low": 1, "medium": 2, "high": 3}
-	th := level[failOn]
-	if th == 0 {
-		th = 2
+var severityLevels = map[string]int{"low": 1, "medium": 2, "high": 3}
+
+// failThreshold normalises a --fail-on value; unknown values mean medium.
+func failThreshold(failOn string) string {
+	if severityLevels[failOn] == 0 {
+		return "medium"
 	}
+	return failOn
+}
+
+// meetsThreshold reports whether a finding of severity s is at or above failOn.
+func meetsThreshold(s types.Severity, failOn string) bool {
+	return severityLevels[string(s)] >= severityLevels[failThreshold(failOn)]
+}
+
+func ShouldFail(findings []types.Finding, failOn string) bool {
 	for _, f := range findings {
-		if level[string(f.Severity)] >= th {
+		if meetsThreshold(f.Severity, failOn) {
 			return true
 		}
 	}
@@ -0,0 +1,72 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/varalys/redactyl/internal/types"
+)
+
+// codeClimateIssue is one entry of a CodeClimate report, the format GitLab's
+// code quality widget reads.
+type codeClimateIssue struct {
+	Type        string              `json:"type"`
+	CheckName   string              `json:"check_name"`
+	Description string              `json:"description"`
+	Content     *codeClimateContent `json:"content,omitempty"`
+	Categories  []string            `json:"categories"`
+	Severity    string              `json:"severity"`
+	Fingerprint string              `json:"fingerprint"`
+	Location    codeClimateLocation `json:"location"`
+}
+
+type codeClimateContent struct {
+	Body string `json:"body"`
+}
+
+type codeClimateLocation struct {
+	Path  string           `json:"path"`
+	Lines codeClimateLines `json:"lines"`
+}
+
+type codeClimateLines struct {
+	Begin int `json:"begin"`
+}
+
+func codeClimateSeverity(s types.Severity) string {
+	switch s {
+	case types.SevHigh:
+		return "critical"
+	case types.SevMed:
+		return "major"
+	default:
+		return "minor"
+	}
+}
+
+// WriteCodeClimate writes findings as a CodeClimate JSON array. Fingerprints
+// come from FindingKey plus the line, so an issue keeps its identity between
+// pipelines and GitLab can tell new issues from fixed ones. Matches are
+// masked.
+func WriteCodeClimate(w io.Writer, findings []types.Finding) error {
+	issues := []codeClimateIssue{}
+	for _, f := range findings {
+		line := f.Line
+		if line < 1 {
+			line = 1
+		}
+		issues = append(issues, codeClimateIssue{
+			Type:        "issue",
+			CheckName:   f.Detector,
+			Description: f.Detector + ": " + maskValue(f.Match),
+			Content:     &codeClimateContent{Body: Remediation(f)},
+			Categories:  []string{"Security"},
+			Severity:    codeClimateSeverity(f.Severity),
+			Fingerprint: findingID(FindingKey(f), f.Line),
+			Location:    codeClimateLocation{Path: f.Path, Lines: codeClimateLines{Begin: line}},
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(issues)
+}
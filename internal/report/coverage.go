@@ -0,0 +1,79 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/varalys/redactyl/internal/engine"
+)
+
+// PrintCoverage renders a scan's coverage: bytes scanned per artifact type,
+// skipped paths totalled by reason, then every skipped path (with its commit
+// for history scans).
+func PrintCoverage(w io.Writer, cov *engine.Coverage) {
+	if cov == nil {
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "Coverage")
+	_, _ = fmt.Fprintln(tw, "Scanned\tfiles\tbytes")
+	kinds := make([]string, 0, len(cov.ScannedBytes))
+	var files int
+	var bytes int64
+	for k := range cov.ScannedBytes {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	for _, k := range kinds {
+		files += cov.ScannedFiles[k]
+		bytes += cov.ScannedBytes[k]
+		_, _ = fmt.Fprintf(tw, "  %s\t%d\t%s\n", k, cov.ScannedFiles[k], formatSize(cov.ScannedBytes[k]))
+	}
+	_, _ = fmt.Fprintf(tw, "  total\t%d\t%s\n", files, formatSize(bytes))
+
+	totals := cov.Totals()
+	if len(totals) == 0 {
+		_, _ = fmt.Fprintln(tw, "Skipped\tnothing")
+		_ = tw.Flush()
+		return
+	}
+	_, _ = fmt.Fprintln(tw, "Skipped\tpaths\tbytes")
+	var count int
+	bytes = 0
+	for _, t := range totals {
+		count += t.Count
+		bytes += t.Bytes
+		_, _ = fmt.Fprintf(tw, "  %s\t%d\t%s\n", t.Reason, t.Count, formatSize(t.Bytes))
+	}
+	_, _ = fmt.Fprintf(tw, "  total\t%d\t%s\n", count, formatSize(bytes))
+	_ = tw.Flush()
+
+	skipped := append([]engine.SkippedPath(nil), cov.Skipped...)
+	sort.SliceStable(skipped, func(i, j int) bool { return skipped[i].Path < skipped[j].Path })
+	_, _ = fmt.Fprintln(w, "\nSkipped paths")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, s := range skipped {
+		_, _ = fmt.Fprintf(tw, "  %s\t%s\t%s", s.Path, s.Reason, formatSize(s.Size))
+		if s.Commit != "" {
+			_, _ = fmt.Fprintf(tw, "\tcommit %s", shortCommit(s.Commit))
+		}
+		_, _ = fmt.Fprintln(tw)
+	}
+	_ = tw.Flush()
+}
+
+// formatSize renders n bytes with a binary unit, e.g. 1.5 MiB.
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,41 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/varalys/redactyl/internal/engine"
+)
+
+func TestPrintCoverage(t *testing.T) {
+	cov := &engine.Coverage{
+		Skipped: []engine.SkippedPath{
+			{Path: "vendor/", Reason: engine.SkipDefaultExclude, Size: 3 << 20, Dir: true},
+			{Path: "big.bin", Reason: engine.SkipMaxBytes, Size: 2048},
+			{Path: "assets/logo.png", Reason: engine.SkipDefaultExclude, Size: 100},
+			{Path: "bundle.zip", Reason: "artifact-entries", Size: 512},
+			{Path: "dump.sql", Reason: engine.SkipMaxBytes, Size: 4096, Commit: "0123456789abcdef0123"},
+		},
+		ScannedBytes: map[string]int64{engine.KindFile: 1536, engine.KindArchive: 10},
+		ScannedFiles: map[string]int{engine.KindFile: 3, engine.KindArchive: 1},
+	}
+	var buf bytes.Buffer
+	PrintCoverage(&buf, cov)
+	out := buf.String()
+	flat := strings.Join(strings.Fields(out), " ")
+	for _, want := range []string{
+		"file 3 1.5 KiB total 4 1.5 KiB",
+		"default-exclude 2 3.0 MiB max-bytes 2 6.0 KiB total 5 3.0 MiB",
+		"dump.sql max-bytes 4.0 KiB commit 0123456789ab",
+		"artifact-entries 1 512 B",
+		"vendor/ default-exclude 3.0 MiB",
+	} {
+		if !strings.Contains(flat, want) {
+			t.Fatalf("expected %q in coverage report:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "assets/logo.png") > strings.Index(out, "big.bin") {
+		t.Fatalf("expected skipped paths sorted:\n%s", out)
+	}
+}
@@ -0,0 +1,174 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/varalys/redactyl/internal/types"
+)
+
+// gitLabSchemaVersion is the GitLab security report schema the secret
+// detection report follows.
+const gitLabSchemaVersion = "15.0.7"
+
+// gitLabTimeFormat is the schema's timestamp format: UTC, no zone suffix.
+const gitLabTimeFormat = "2006-01-02T15:04:05"
+
+// GitLabOptions controls WriteGitLabSecretDetection.
+type GitLabOptions struct {
+	Version string    // redactyl version
+	Commit  string    // commit scanned; used for findings without commit metadata
+	Start   time.Time // scan start; defaults to End
+	End     time.Time // scan end; defaults to now
+}
+
+type gitLabReport struct {
+	Version         string                `json:"version"`
+	Vulnerabilities []gitLabVulnerability `json:"vulnerabilities"`
+	Scan            gitLabScan            `json:"scan"`
+}
+
+type gitLabVulnerability struct {
+	ID          string             `json:"id"`
+	Category    string             `json:"category"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Severity    string             `json:"severity"`
+	Solution    string             `json:"solution,omitempty"`
+	Scanner     gitLabRef          `json:"scanner"`
+	Location    gitLabLocation     `json:"location"`
+	Identifiers []gitLabIdentifier `json:"identifiers"`
+	RawExtract  string             `json:"raw_source_code_extract,omitempty"`
+}
+
+type gitLabRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type gitLabLocation struct {
+	File      string       `json:"file"`
+	StartLine int          `json:"start_line,omitempty"`
+	EndLine   int          `json:"end_line,omitempty"`
+	Commit    gitLabCommit `json:"commit"`
+}
+
+type gitLabCommit struct {
+	SHA     string `json:"sha"`
+	Author  string `json:"author,omitempty"`
+	Date    string `json:"date,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type gitLabIdentifier struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	URL   string `json:"url,omitempty"`
+}
+
+type gitLabScan struct {
+	Analyzer  gitLabTool `json:"analyzer"`
+	Scanner   gitLabTool `json:"scanner"`
+	Type      string     `json:"type"`
+	StartTime string     `json:"start_time"`
+	EndTime   string     `json:"end_time"`
+	Status    string     `json:"status"`
+}
+
+type gitLabTool struct {
+	ID      string     `json:"id"`
+	Name    string     `json:"name"`
+	Version string     `json:"version"`
+	Vendor  gitLabName `json:"vendor"`
+	URL     string     `json:"url,omitempty"`
+}
+
+type gitLabName struct {
+	Name string `json:"name"`
+}
+
+func gitLabSeverity(s types.Severity) string {
+	switch s {
+	case types.SevHigh:
+		return "High"
+	case types.SevMed:
+		return "Medium"
+	case types.SevLow:
+		return "Low"
+	}
+	return "Unknown"
+}
+
+// WriteGitLabSecretDetection writes findings as a GitLab secret detection
+// report (gl-secret-detection-report.json) so they show up in merge request
+// widgets and the vulnerability report. Each vulnerability carries the
+// finding's FindingKey as an identifier, which keeps it stable across
+// pipelines. Matches are masked; the secret itself never leaves the runner.
+func WriteGitLabSecretDetection(w io.Writer, findings []types.Finding, opts GitLabOptions) error {
+	if opts.End.IsZero() {
+		opts.End = time.Now()
+	}
+	if opts.Start.IsZero() {
+		opts.Start = opts.End
+	}
+	if opts.Commit == "" {
+		// The schema requires a commit; GitLab itself uses this placeholder.
+		opts.Commit = "0000000"
+	}
+	tool := gitLabTool{ID: "redactyl", Name: "Redactyl", Version: opts.Version, Vendor: gitLabName{Name: "Varalys"}, URL: "https://github.com/varalys/redactyl"}
+	doc := gitLabReport{
+		Version:         gitLabSchemaVersion,
+		Vulnerabilities: []gitLabVulnerability{},
+		Scan: gitLabScan{
+			Analyzer:  tool,
+			Scanner:   tool,
+			Type:      "secret_detection",
+			StartTime: opts.Start.UTC().Format(gitLabTimeFormat),
+			EndTime:   opts.End.UTC().Format(gitLabTimeFormat),
+			Status:    "success",
+		},
+	}
+	for _, f := range findings {
+		key := FindingKey(f)
+		commit := gitLabCommit{SHA: opts.Commit}
+		if sha := f.Metadata["commit"]; sha != "" {
+			commit = gitLabCommit{SHA: sha, Author: f.Metadata["author"], Date: f.Metadata["date"], Message: f.Metadata["commit_subject"]}
+		}
+		doc.Vulnerabilities = append(doc.Vulnerabilities, gitLabVulnerability{
+			ID:          findingID(key, f.Line),
+			Category:    "secret_detection",
+			Name:        f.Detector,
+			Description: f.Detector + " detected in " + f.Path,
+			Severity:    gitLabSeverity(f.Severity),
+			Solution:    Remediation(f),
+			Scanner:     gitLabRef{ID: "redactyl", Name: "Redactyl"},
+			Location:    gitLabLocation{File: f.Path, StartLine: f.Line, EndLine: f.Line, Commit: commit},
+			Identifiers: []gitLabIdentifier{
+				{Type: "redactyl_rule_id", Name: "Redactyl rule " + f.Detector, Value: f.Detector, URL: ruleHelpURI(f.Detector)},
+				{Type: "redactyl_fingerprint", Name: "Redactyl fingerprint", Value: key},
+			},
+			RawExtract: maskValue(f.Match),
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// findingID derives a UUID-shaped ID for one occurrence of a finding. The
+// FindingKey alone is shared by every line where the same secret appears in
+// the same file, so the line is mixed in.
+func findingID(key string, line int) string {
+	sum := sha256.Sum256([]byte(key + ":" + strconv.Itoa(line)))
+	h := hex.EncodeToString(sum[:16])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+func ruleHelpURI(detector string) string {
+	return "https://github.com/varalys/redactyl/blob/main/docs/rules/README.md#" + detector
+}
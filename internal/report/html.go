@@ -0,0 +1,258 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/varalys/redactyl/internal/engine"
+	"github.com/varalys/redactyl/internal/git"
+	"github.com/varalys/redactyl/internal/types"
+)
+
+//go:embed templates/report.html
+var htmlTemplate string
+
+var htmlTmpl = template.Must(template.New("report").Parse(htmlTemplate))
+
+// HTMLOptions controls WriteHTML.
+type HTMLOptions struct {
+	Title       string    // page title; defaults to "Redactyl scan report"
+	Target      string    // what was scanned, e.g. a path or repository URL
+	Version     string    // redactyl version
+	Generated   time.Time // defaults to now
+	ShowSecrets bool      // include unmasked matches (masked by default)
+}
+
+type htmlBar struct {
+	Label   string
+	Count   int
+	Percent int
+}
+
+type htmlMeta struct {
+	Key, Value string
+}
+
+type htmlFinding struct {
+	Path        string
+	Line        int
+	Column      int
+	Detector    string
+	Severity    string
+	Confidence  string
+	Match       string
+	Context     string
+	Remediation string
+	Metadata    []htmlMeta
+}
+
+// htmlNode is one level of a virtual path: a file, or an archive, image or
+// layer that contains further entries.
+type htmlNode struct {
+	Name     string
+	Kind     string // artifact, archive, layer or file
+	Count    int    // findings in this node and below
+	Worst    string // highest severity in this node and below
+	Findings []htmlFinding
+	Children []*htmlNode
+}
+
+type htmlReport struct {
+	Title        string
+	Target       string
+	Version      string
+	Generated    string
+	Duration     string
+	FilesScanned int
+	Total        int
+	Severities   []htmlBar
+	Detectors    []htmlBar
+	Tree         []*htmlNode
+	Stats        engine.DeepStats
+	Aborted      int
+	Errors       []string
+}
+
+// layerEntry matches the start of a container layer entry in a virtual
+// path: a docker save layer ID or an OCI digest, then the file in the layer.
+var layerEntry = regexp.MustCompile(`^((?:sha256:)?[0-9a-f]{64})(?:/(.+))?$`)
+
+// WriteHTML writes res as a single self-contained HTML page: severity and
+// detector charts, findings grouped by artifact hierarchy (archive → layer →
+// file), per-finding context and remediation, artifact limit and error
+// summaries, and client-side filtering. All styles and scripts are inline,
+// so the page works offline. Matches are masked unless opts.ShowSecrets is
+// set.
+func WriteHTML(w io.Writer, res engine.Result, opts HTMLOptions) error {
+	if opts.Title == "" {
+		opts.Title = "Redactyl scan report"
+	}
+	if opts.Generated.IsZero() {
+		opts.Generated = time.Now()
+	}
+	r := htmlReport{
+		Title:        opts.Title,
+		Target:       opts.Target,
+		Version:      opts.Version,
+		Generated:    opts.Generated.Format(time.RFC1123),
+		FilesScanned: res.FilesScanned,
+		Total:        len(res.Findings),
+		Stats:        res.ArtifactStats,
+		Aborted:      res.ArtifactStats.AbortedByBytes + res.ArtifactStats.AbortedByEntries + res.ArtifactStats.AbortedByDepth + res.ArtifactStats.AbortedByTime,
+	}
+	if res.Duration > 0 {
+		r.Duration = fmt.Sprintf("%.2fs", res.Duration.Seconds())
+	}
+	for _, err := range res.ArtifactErrors {
+		if err != nil {
+			r.Errors = append(r.Errors, err.Error())
+		}
+	}
+
+	findings := append([]types.Finding(nil), res.Findings...)
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Path == findings[j].Path {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Path < findings[j].Path
+	})
+	sevCount := map[types.Severity]int{}
+	detCount := map[string]int{}
+	root := &htmlNode{}
+	for _, f := range findings {
+		sevCount[f.Severity]++
+		detCount[f.Detector]++
+		insertFinding(root, f, htmlFindingFor(f, opts.ShowSecrets))
+	}
+	r.Tree = root.Children
+	for _, s := range []types.Severity{types.SevHigh, types.SevMed, types.SevLow} {
+		r.Severities = append(r.Severities, bar(string(s), sevCount[s], len(findings)))
+	}
+	for d, n := range detCount {
+		r.Detectors = append(r.Detectors, bar(d, n, len(findings)))
+	}
+	sort.Slice(r.Detectors, func(i, j int) bool {
+		if r.Detectors[i].Count == r.Detectors[j].Count {
+			return r.Detectors[i].Label < r.Detectors[j].Label
+		}
+		return r.Detectors[i].Count > r.Detectors[j].Count
+	})
+	return htmlTmpl.Execute(w, r)
+}
+
+func bar(label string, n, total int) htmlBar {
+	b := htmlBar{Label: label, Count: n}
+	if total > 0 {
+		b.Percent = n * 100 / total
+	}
+	return b
+}
+
+type htmlSegment struct {
+	Name, Kind string
+}
+
+// virtualSegments splits a virtual path such as image.tar::<layer>/etc/app.env
+// into its levels: the artifact on disk, nested archives, container layers
+// and finally the file. Commit and tag message paths also contain "::" but
+// are a single entry.
+func virtualSegments(p string) []htmlSegment {
+	if git.IsMessagePath(p) || !strings.Contains(p, "::") {
+		return []htmlSegment{{p, "file"}}
+	}
+	parts := strings.Split(p, "::")
+	segs := []htmlSegment{{parts[0], "artifact"}}
+	for i, part := range parts[1:] {
+		if m := layerEntry.FindStringSubmatch(part); m != nil {
+			segs = append(segs, htmlSegment{m[1], "layer"})
+			if part = m[2]; part == "" {
+				continue
+			}
+		}
+		kind := "archive"
+		if i == len(parts)-2 {
+			kind = "file"
+		}
+		segs = append(segs, htmlSegment{part, kind})
+	}
+	return segs
+}
+
+// insertFinding adds f under the node for each level of its virtual path.
+func insertFinding(root *htmlNode, f types.Finding, hf htmlFinding) {
+	node := root
+	for _, seg := range virtualSegments(f.Path) {
+		var next *htmlNode
+		for _, c := range node.Children {
+			if c.Name == seg.Name && c.Kind == seg.Kind {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			next = &htmlNode{Name: seg.Name, Kind: seg.Kind}
+			node.Children = append(node.Children, next)
+		}
+		next.Count++
+		if severityRank(f.Severity) > severityRank(types.Severity(next.Worst)) {
+			next.Worst = string(f.Severity)
+		}
+		node = next
+	}
+	node.Findings = append(node.Findings, hf)
+}
+
+func severityRank(s types.Severity) int {
+	switch s {
+	case types.SevHigh:
+		return 3
+	case types.SevMed:
+		return 2
+	case types.SevLow:
+		return 1
+	}
+	return 0
+}
+
+func htmlFindingFor(f types.Finding, showSecrets bool) htmlFinding {
+	hide := func(s string) string { return s }
+	match := f.Match
+	if !showSecrets {
+		match = maskValue(f.Match)
+		// Context and metadata may quote the secret; mask it there too.
+		var pairs []string
+		for _, v := range []string{f.Secret, f.Match} {
+			if len(v) >= 4 {
+				pairs = append(pairs, v, maskValue(v))
+			}
+		}
+		r := strings.NewReplacer(pairs...)
+		hide = r.Replace
+	}
+	hf := htmlFinding{
+		Path:        f.Path,
+		Line:        f.Line,
+		Column:      f.Column,
+		Detector:    f.Detector,
+		Severity:    string(f.Severity),
+		Confidence:  fmt.Sprintf("%.2f", f.Confidence),
+		Match:       match,
+		Context:     hide(f.Context),
+		Remediation: Remediation(f),
+	}
+	keys := make([]string, 0, len(f.Metadata))
+	for k := range f.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		hf.Metadata = append(hf.Metadata, htmlMeta{Key: k, Value: hide(f.Metadata[k])})
+	}
+	return hf
+}
@@ -0,0 +1,199 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/varalys/redactyl/internal/engine"
+	"github.com/varalys/redactyl/internal/git"
+	"github.com/varalys/redactyl/internal/types"
+)
+
+// JUnitOptions controls WriteJUnit.
+type JUnitOptions struct {
+	FailOn    string    // low, medium or high; findings at or above it fail their testcase
+	Timestamp time.Time // defaults to now
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Errors    int         `xml:"errors,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitModes is the order testsuites are written in.
+var junitModes = []string{engine.ModeFilesystem, engine.ModeHistory, engine.ModeArtifacts}
+
+// WriteJUnit writes the scan as JUnit XML, with one testsuite per scan mode
+// (filesystem, history, artifacts) and one testcase per scanned file or
+// artifact. A testcase fails when it has findings at or above opts.FailOn;
+// the failure lists each one's line, detector, severity and masked match.
+// Findings below the threshold are listed in the testcase's system-out.
+// Testcases come from res.Scanned (engine.Config.RecordScanned), plus any
+// path with findings that is not listed there, such as commit messages.
+func WriteJUnit(w io.Writer, res engine.Result, opts JUnitOptions) error {
+	if opts.Timestamp.IsZero() {
+		opts.Timestamp = time.Now()
+	}
+	// cases[mode][name] holds the findings of each testcase.
+	cases := map[string]map[string][]types.Finding{}
+	add := func(mode, name string) {
+		if cases[mode] == nil {
+			cases[mode] = map[string][]types.Finding{}
+		}
+		if _, ok := cases[mode][name]; !ok {
+			cases[mode][name] = nil
+		}
+	}
+	artifactPaths := map[string]bool{}
+	for _, s := range res.Scanned {
+		if s.Mode == engine.ModeArtifacts {
+			artifactPaths[s.Path] = true
+		}
+		add(s.Mode, junitCaseName(s.Mode, s.Path))
+	}
+	for _, f := range res.Findings {
+		mode := junitFindingMode(f, artifactPaths)
+		name := junitCaseName(mode, f.Path)
+		add(mode, name)
+		cases[mode][name] = append(cases[mode][name], f)
+	}
+
+	doc := junitSuites{Name: "redactyl", Time: junitSeconds(res.Duration)}
+	stamp := opts.Timestamp.UTC().Format("2006-01-02T15:04:05")
+	for _, mode := range junitModes {
+		if len(cases[mode]) == 0 {
+			continue
+		}
+		suite := junitSuite{Name: mode, Timestamp: stamp}
+		names := make([]string, 0, len(cases[mode]))
+		for name := range cases[mode] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			c := junitTestcase(mode, name, cases[mode][name], opts.FailOn)
+			if c.Failure != nil {
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, c)
+		}
+		suite.Tests = len(suite.Cases)
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+		doc.Suites = append(doc.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitTestcase(mode, name string, findings []types.Finding, failOn string) junitCase {
+	c := junitCase{Name: name, Classname: "redactyl." + mode, Time: "0"}
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Line < findings[j].Line })
+	var failing, other []string
+	failType := ""
+	for _, f := range findings {
+		line := junitFindingLine(f)
+		if meetsThreshold(f.Severity, failOn) {
+			failing = append(failing, line)
+			if failType == "" {
+				failType = f.Detector
+			}
+		} else {
+			other = append(other, line)
+		}
+	}
+	if len(failing) > 0 {
+		c.Failure = &junitFailure{
+			Message: fmt.Sprintf("%d secret(s) at or above %s severity", len(failing), failThreshold(failOn)),
+			Type:    failType,
+			Text:    strings.Join(failing, "\n"),
+		}
+	}
+	if len(other) > 0 {
+		c.SystemOut = "Below threshold:\n" + strings.Join(other, "\n")
+	}
+	return c
+}
+
+func junitFindingLine(f types.Finding) string {
+	s := fmt.Sprintf("line %d: %s (%s) %s", f.Line, f.Detector, f.Severity, maskValue(f.Match))
+	// Show where inside an artifact the secret is, since the testcase
+	// is the outer artifact.
+	if _, inner, ok := strings.Cut(f.Path, "::"); ok && !git.IsMessagePath(f.Path) {
+		s += " in " + inner
+	}
+	if c := f.Metadata["commit"]; c != "" {
+		s += " [commit " + c + "]"
+	}
+	return s
+}
+
+// junitFindingMode picks the testsuite for a finding: history for anything
+// attributed to a commit, ref or stash, artifacts for virtual paths and
+// analysed artifact files, filesystem otherwise.
+func junitFindingMode(f types.Finding, artifactPaths map[string]bool) string {
+	switch {
+	case git.IsMessagePath(f.Path),
+		f.Metadata["commit"] != "", f.Metadata["history_status"] != "",
+		f.Metadata["ref"] != "", f.Metadata["stash"] != "":
+		return engine.ModeHistory
+	case strings.Contains(f.Path, "::"), artifactPaths[f.Path]:
+		return engine.ModeArtifacts
+	}
+	return engine.ModeFilesystem
+}
+
+// junitCaseName is the testcase a path belongs to: artifact entries are
+// grouped under the artifact on disk or in the registry.
+func junitCaseName(mode, p string) string {
+	if mode == engine.ModeArtifacts && !git.IsMessagePath(p) {
+		if outer, _, ok := strings.Cut(p, "::"); ok {
+			return outer
+		}
+	}
+	return p
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,138 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/varalys/redactyl/internal/git"
+	"github.com/varalys/redactyl/internal/types"
+)
+
+// MarkdownOptions controls WriteMarkdownSummary.
+type MarkdownOptions struct {
+	Target string // what was scanned, shown in the heading
+	// BlobURL is the base for file links, e.g.
+	// https://github.com/owner/repo/blob. Links are omitted when empty.
+	BlobURL string
+	Commit  string // commit file links point at, unless a finding has its own
+}
+
+// WriteMarkdownSummary writes a compact Markdown summary for pull request
+// comments and CI job summaries: counts by severity, a table of new findings
+// with masked values and links to each file and line, remediation hints,
+// and baselined findings in a collapsed section.
+func WriteMarkdownSummary(w io.Writer, findings, baselined []types.Finding, opts MarkdownOptions) error {
+	var b strings.Builder
+	b.WriteString("## Redactyl secret scan")
+	if opts.Target != "" {
+		b.WriteString(": " + markdownCell(opts.Target))
+	}
+	b.WriteString("\n\n")
+	switch len(findings) {
+	case 0:
+		b.WriteString("No new secrets found ✅")
+	case 1:
+		b.WriteString("**1 new finding**")
+	default:
+		fmt.Fprintf(&b, "**%d new findings**", len(findings))
+	}
+	if opts.Commit != "" {
+		fmt.Fprintf(&b, " at `%s`", shortCommit(opts.Commit))
+	}
+	b.WriteString("\n\n| Severity | New | Baselined |\n| --- | ---: | ---: |\n")
+	for _, sev := range []types.Severity{types.SevHigh, types.SevMed, types.SevLow} {
+		fmt.Fprintf(&b, "| %s | %d | %d |\n", strings.ToUpper(string(sev[:1]))+string(sev[1:]), countSev(findings, sev), countSev(baselined, sev))
+	}
+
+	link := func(f types.Finding) string { return markdownLink(f, opts) }
+	if len(findings) > 0 {
+		b.WriteString("\n### New findings\n\n")
+		writeMarkdownFindings(&b, findings, link)
+
+		b.WriteString("\n### Remediation\n\n")
+		seen := map[string]bool{}
+		for _, f := range sortedBySeverity(findings) {
+			if r := Remediation(f); !seen[r] {
+				seen[r] = true
+				b.WriteString("- " + r + "\n")
+			}
+		}
+	}
+	if len(baselined) > 0 {
+		fmt.Fprintf(&b, "\n<details>\n<summary>Baselined findings (%d)</summary>\n\n", len(baselined))
+		writeMarkdownFindings(&b, baselined, link)
+		b.WriteString("\n</details>\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdownFindings writes a table of findings, most severe first.
+// link returns the URL for a finding's location, or "" for none.
+func writeMarkdownFindings(b *strings.Builder, findings []types.Finding, link func(types.Finding) string) {
+	b.WriteString("| Severity | Detector | Location | Match |\n| --- | --- | --- | --- |\n")
+	for _, f := range sortedBySeverity(findings) {
+		loc := f.Path
+		if f.Line > 0 {
+			loc += ":" + strconv.Itoa(f.Line)
+		}
+		cell := "`" + markdownCell(loc) + "`"
+		if link != nil {
+			if u := link(f); u != "" {
+				cell = "[" + cell + "](" + u + ")"
+			}
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | `%s` |\n", f.Severity, markdownCell(f.Detector), cell, markdownCell(maskValue(f.Match)))
+	}
+}
+
+// markdownLink returns a link to the finding's file and line at the commit
+// that introduced it, or at opts.Commit. Files inside artifacts and commit
+// messages have no such page, so they get no link.
+func markdownLink(f types.Finding, opts MarkdownOptions) string {
+	commit := f.Metadata["commit"]
+	if commit == "" {
+		commit = opts.Commit
+	}
+	if opts.BlobURL == "" || commit == "" || strings.Contains(f.Path, "::") || git.IsMessagePath(f.Path) {
+		return ""
+	}
+	parts := strings.Split(f.Path, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	u := strings.TrimSuffix(opts.BlobURL, "/") + "/" + commit + "/" + strings.Join(parts, "/")
+	if f.Line > 0 {
+		u += "#L" + strconv.Itoa(f.Line)
+	}
+	return u
+}
+
+func sortedBySeverity(findings []types.Finding) []types.Finding {
+	sorted := append([]types.Finding(nil), findings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return severityRank(sorted[i].Severity) > severityRank(sorted[j].Severity)
+	})
+	return sorted
+}
+
+func countSev(findings []types.Finding, sev types.Severity) int {
+	n := 0
+	for _, f := range findings {
+		if f.Severity == sev {
+			n++
+		}
+	}
+	return n
+}
+
+func shortCommit(c string) string {
+	if len(c) > 12 {
+		return c[:12]
+	}
+	return c
+}
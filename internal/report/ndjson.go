@@ -0,0 +1,52 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/varalys/redactyl/internal/engine"
+	"github.com/varalys/redactyl/internal/types"
+)
+
+// NDJSON record types, in the "type" field of every line.
+const (
+	NDJSONFinding = "finding"
+	NDJSONStats   = "stats"
+)
+
+// NDJSONSummary is the last record of an NDJSON stream.
+type NDJSONSummary struct {
+	FilesScanned  int                       `json:"files_scanned"`
+	Findings      int                       `json:"findings"`       // finding records written
+	TotalFindings int                       `json:"total_findings"` // including baselined findings
+	DurationMS    int64                     `json:"duration_ms"`
+	ArtifactStats map[string]int            `json:"artifact_stats"`
+	LFSMissing    []engine.MissingLFSObject `json:"lfs_missing,omitempty"`
+	Coverage      *engine.Coverage          `json:"coverage,omitempty"` // --coverage only
+}
+
+// WriteNDJSONFinding writes f as one line of JSON with "type":"finding" and
+// the same fields as scan --json.
+func WriteNDJSONFinding(w io.Writer, f types.Finding) error {
+	return writeNDJSON(w, struct {
+		Type string `json:"type"`
+		types.Finding
+	}{NDJSONFinding, f})
+}
+
+// WriteNDJSONStats writes the closing "type":"stats" record.
+func WriteNDJSONStats(w io.Writer, s NDJSONSummary) error {
+	return writeNDJSON(w, struct {
+		Type string `json:"type"`
+		NDJSONSummary
+	}{NDJSONStats, s})
+}
+
+func writeNDJSON(w io.Writer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
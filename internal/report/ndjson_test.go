@@ -0,0 +1,43 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/varalys/redactyl/internal/types"
+)
+
+func TestWriteNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	f := types.Finding{Path: "a.env", Line: 3, Detector: "github-pat", Severity: types.SevHigh, Match: "ghp_x", Metadata: map[string]string{"commit": "abc"}}
+	if err := WriteNDJSONFinding(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteNDJSONStats(&buf, NDJSONSummary{FilesScanned: 2, Findings: 1, TotalFindings: 1, ArtifactStats: map[string]int{"bytes": 0}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []map[string]any
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var m map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &m); err != nil {
+			t.Fatalf("line is not JSON: %q", sc.Text())
+		}
+		lines = append(lines, m)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	if lines[0]["type"] != NDJSONFinding || lines[0]["path"] != "a.env" || lines[0]["detector"] != "github-pat" {
+		t.Fatalf("finding record should flatten the finding fields: %v", lines[0])
+	}
+	if lines[1]["type"] != NDJSONStats || lines[1]["files_scanned"] != float64(2) {
+		t.Fatalf("unexpected stats record: %v", lines[1])
+	}
+	if _, ok := lines[1]["lfs_missing"]; ok {
+		t.Fatalf("lfs_missing should be omitted when empty: %v", lines[1])
+	}
+}
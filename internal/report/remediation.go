@@ -0,0 +1,42 @@
+package report
+
+import (
+	"strings"
+
+	"github.com/varalys/redactyl/internal/engine"
+	"github.com/varalys/redactyl/internal/git"
+	"github.com/varalys/redactyl/internal/types"
+)
+
+const pruneCommand = "git reflog expire --expire-unreachable=now --all && git gc --prune=now"
+
+// Remediation returns a one-line suggestion for fixing a finding, based on
+// where the secret lives. It never includes the secret itself; every
+// suggestion starts with rotating the credential, since anything that
+// reached a repository or artifact must be treated as leaked.
+func Remediation(f types.Finding) string {
+	switch f.Metadata["history_status"] {
+	case engine.HistoryStash:
+		stash := f.Metadata["stash"]
+		if stash == "" {
+			stash = "<stash>"
+		}
+		return "Rotate the credential, then drop the stash and prune: git stash drop " + stash + " && " + pruneCommand
+	case engine.HistoryReflog, engine.HistoryUnreachable:
+		return "Rotate the credential, then prune unreachable objects: " + pruneCommand
+	}
+	if git.IsMessagePath(f.Path) {
+		return "Rotate the credential. Commit and tag messages can only be changed by rewriting history (git filter-repo --message-callback)."
+	}
+	if outer, _, ok := strings.Cut(f.Path, "::"); ok {
+		return "Rotate the credential and rebuild " + outer + " without it; republish any copies already distributed."
+	}
+	switch f.Metadata["history_status"] {
+	case engine.HistoryRemoved, engine.HistoryUnmerged:
+		return "Rotate the credential, then rewrite history: redactyl purge path " + f.Path + " --backup-branch backup/pre-purge"
+	}
+	if strings.Contains(strings.ToLower(f.Path), ".env") {
+		return "Rotate the credential and move it out of the repository: redactyl fix dotenv --from " + f.Path + " --add-ignore"
+	}
+	return "Rotate the credential and replace it: redactyl fix redact --file " + f.Path + " --pattern '<secret>' --replace '<redacted>'"
+}
@@ -47,7 +47,11 @@ func PrintText(w io.Writer, findings []types.Finding, opts PrintOptions) {
 				sev = colorSeverity(f.Severity)
 			}
 			mask := maskValue(f.Match)
-			fmt.Fprintf(w, "%-6s %-*s %s:%d  %s\n", sev, maxDet, f.Detector, f.Path, f.Line, mask)
+			loc := f.Path + ":" + strconv.Itoa(f.Line)
+			if addr := f.Metadata["iac_address"]; addr != "" {
+				loc += " (" + addr + ")"
+			}
+			fmt.Fprintf(w, "%-6s %-*s %s  %s\n", sev, maxDet, f.Detector, loc, mask)
 		}
 	}
 	// Summary footer
@@ -4,6 +4,9 @@ package report
 import (
 	"encoding/json"
 	"io"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/varalys/redactyl/internal/types"
@@ -15,9 +18,20 @@ type sarif struct {
 }
 
 type sarifRun struct {
-	Tool       sarifTool              `json:"tool"`
-	Results    []sarifResult          `json:"results"`
-	Properties map[string]interface{} `json:"properties,omitempty"`
+	Tool                     sarifTool              `json:"tool"`
+	VersionControlProvenance []sarifVCS             `json:"versionControlProvenance,omitempty"`
+	Artifacts                []sarifArtifact        `json:"artifacts,omitempty"`
+	Results                  []sarifResult          `json:"results"`
+	Properties               map[string]interface{} `json:"properties,omitempty"`
+}
+
+// sarifVCS is a versionControlDetails object describing a commit that
+// introduced one or more results.
+type sarifVCS struct {
+	RepositoryURI string            `json:"repositoryUri"`
+	RevisionID    string            `json:"revisionId,omitempty"`
+	AsOfTimeUTC   string            `json:"asOfTimeUtc,omitempty"`
+	Properties    map[string]string `json:"properties,omitempty"`
 }
 
 type sarifTool struct {
@@ -25,21 +39,32 @@ type sarifTool struct {
 }
 
 type sarifDriver struct {
-	Name    string      `json:"name"`
-	Version string      `json:"version"`
-	Rules   []sarifRule `json:"rules,omitempty"`
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules,omitempty"`
 }
 
 type sarifResult struct {
-	RuleID    string       `json:"ruleId"`
-	Level     string       `json:"level"`
-	Message   sarifMessage `json:"message"`
-	Locations []sarifLoc   `json:"locations"`
-	RuleIndex int          `json:"ruleIndex,omitempty"`
+	RuleID              string                 `json:"ruleId,omitempty"`
+	Level               string                 `json:"level,omitempty"`
+	Message             sarifMessage           `json:"message"`
+	Locations           []sarifLoc             `json:"locations,omitempty"`
+	RuleIndex           int                    `json:"ruleIndex,omitempty"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints,omitempty"`
+	BaselineState       string                 `json:"baselineState,omitempty"`
+	Suppressions        []sarifSuppression     `json:"suppressions,omitempty"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
 }
 
 type sarifMessage struct {
-	Text string `json:"text"`
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
 }
 
 type sarifLoc struct {
@@ -52,12 +77,21 @@ type sarifPhys struct {
 }
 
 type sarifArt struct {
-	URI string `json:"uri"`
+	URI   string `json:"uri"`
+	Index *int   `json:"index,omitempty"`
+}
+
+// sarifArtifact is an entry of run.artifacts. Files inside archives, images
+// and layers point at their container through ParentIndex.
+type sarifArtifact struct {
+	Location    sarifArt `json:"location"`
+	ParentIndex *int     `json:"parentIndex,omitempty"`
 }
 
 type sarifRegion struct {
-	StartLine int           `json:"startLine"`
-	Snippet   *sarifSnippet `json:"snippet,omitempty"`
+	StartLine   int           `json:"startLine"`
+	StartColumn int           `json:"startColumn,omitempty"`
+	Snippet     *sarifSnippet `json:"snippet,omitempty"`
 }
 
 type sarifSnippet struct {
@@ -65,11 +99,95 @@ type sarifSnippet struct {
 }
 
 type sarifRule struct {
-	ID           string        `json:"id"`
-	ShortDesc    *sarifMessage `json:"shortDescription,omitempty"`
-	Help         *sarifMessage `json:"help,omitempty"`
-	HelpURI      string        `json:"helpUri,omitempty"`
-	DefaultLevel string        `json:"defaultConfiguration,omitempty"`
+	ID           string                 `json:"id"`
+	Name         string                 `json:"name,omitempty"`
+	ShortDesc    *sarifMessage          `json:"shortDescription,omitempty"`
+	FullDesc     *sarifMessage          `json:"fullDescription,omitempty"`
+	Help         *sarifMessage          `json:"help,omitempty"`
+	HelpURI      string                 `json:"helpUri,omitempty"`
+	DefaultLevel *sarifRuleConfig       `json:"defaultConfiguration,omitempty"`
+	Properties   map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifRuleConfig struct {
+	Level string `json:"level"`
+}
+
+// commitProperties maps commit attribution metadata on history and diff
+// findings to SARIF property names.
+var commitProperties = []struct{ key, prop string }{
+	{"commit", "commit"},
+	{"author", "author"},
+	{"author_email", "authorEmail"},
+	{"date", "authorDate"},
+	{"commit_subject", "commitSubject"},
+	{"history_status", "historyStatus"},
+}
+
+// resultProperties returns the property bag for a result: the commit that
+// introduced it and whether it is still present at HEAD, when known, plus
+// the finding fields SARIF has no place for, so that ReadSARIF can rebuild
+// the finding exactly. All values are strings.
+func resultProperties(f types.Finding) map[string]interface{} {
+	props := map[string]interface{}{}
+	mapped := map[string]bool{}
+	for _, p := range commitProperties {
+		mapped[p.key] = true
+		if v := f.Metadata[p.key]; v != "" {
+			props[p.prop] = v
+		}
+	}
+	for k, v := range f.Metadata {
+		if !mapped[k] || v == "" {
+			props[metadataPropPrefix+k] = v
+		}
+	}
+	if f.Confidence != 0 {
+		props["confidence"] = strconv.FormatFloat(f.Confidence, 'g', -1, 64)
+	}
+	if f.Context != "" {
+		props["context"] = f.Context
+	}
+	if f.Secret != "" {
+		props["secret"] = f.Secret
+	}
+	if len(virtualSegments(f.Path)) > 1 {
+		props["virtualPath"] = f.Path
+	}
+	if levelSeverity(sevToLevel(f.Severity)) != f.Severity {
+		props["severity"] = string(f.Severity)
+	}
+	if len(props) == 0 {
+		return nil
+	}
+	return props
+}
+
+// metadataPropPrefix marks result properties that hold finding metadata
+// without a dedicated SARIF property name.
+const metadataPropPrefix = "metadata."
+
+// versionControlProvenance lists each distinct introducing commit once so
+// consumers can see which revisions of which repository the results came from.
+func versionControlProvenance(findings []types.Finding) []sarifVCS {
+	var out []sarifVCS
+	seen := map[string]bool{}
+	for _, f := range findings {
+		commit, repo := f.Metadata["commit"], f.Metadata["repository"]
+		if commit == "" || repo == "" || seen[repo+"@"+commit] {
+			continue
+		}
+		seen[repo+"@"+commit] = true
+		vcs := sarifVCS{RepositoryURI: repo, RevisionID: commit}
+		if t, err := time.Parse(time.RFC3339, f.Metadata["date"]); err == nil {
+			vcs.AsOfTimeUTC = t.UTC().Format(time.RFC3339)
+		}
+		if f.Metadata["author"] != "" || f.Metadata["commit_subject"] != "" {
+			vcs.Properties = map[string]string{"author": f.Metadata["author"], "authorEmail": f.Metadata["author_email"], "commitSubject": f.Metadata["commit_subject"]}
+		}
+		out = append(out, vcs)
+	}
+	return out
 }
 
 func sevToLevel(s types.Severity) string {
@@ -83,36 +201,100 @@ func sevToLevel(s types.Severity) string {
 	}
 }
 
+// securitySeverity maps a severity onto the CVSS-like security-severity
+// scale GitHub code scanning uses to rank alerts.
+func securitySeverity(s types.Severity) string {
+	switch s {
+	case types.SevHigh:
+		return "8.0"
+	case types.SevMed:
+		return "5.5"
+	default:
+		return "2.0"
+	}
+}
+
+// sarifFingerprintKey names the partialFingerprints entry holding
+// FindingKey, so code scanning tools match results across runs.
+const sarifFingerprintKey = "redactylFingerprint/v1"
+
+// SARIFOptions controls WriteSARIFWithOptions.
+type SARIFOptions struct {
+	// ArtifactStats is written to run.properties.artifactStats when set.
+	ArtifactStats map[string]int
+	// Baseline, when set, marks each result new or unchanged and adds an
+	// absent result for each baseline entry no longer found. Unchanged
+	// results also carry an external suppression.
+	Baseline *Baseline
+}
+
 // WriteSARIF writes findings as SARIF 2.1.0 to the provided writer.
 func WriteSARIF(w io.Writer, findings []types.Finding) error {
-	run := sarifRun{Tool: sarifTool{Driver: sarifDriver{Name: "redactyl", Version: time.Now().Format("2006.01.02")}}}
+	return WriteSARIFWithOptions(w, findings, SARIFOptions{})
+}
+
+// WriteSARIFWithStats writes findings as SARIF and includes artifactStats in the run.properties bag.
+func WriteSARIFWithStats(w io.Writer, findings []types.Finding, artifactStats map[string]int) error {
+	return WriteSARIFWithOptions(w, findings, SARIFOptions{ArtifactStats: artifactStats})
+}
+
+// WriteSARIFWithOptions writes findings as SARIF 2.1.0. Every result carries
+// its FindingKey in partialFingerprints. Virtual paths such as
+// chart.tgz::templates/secret.yaml are listed in run.artifacts as nested
+// entries linked by parentIndex, and results reference the innermost one.
+func WriteSARIFWithOptions(w io.Writer, findings []types.Finding, opts SARIFOptions) error {
+	run := sarifRun{Tool: sarifTool{Driver: sarifDriver{
+		Name:           "redactyl",
+		Version:        time.Now().Format("2006.01.02"),
+		InformationURI: "https://github.com/varalys/redactyl",
+	}}}
 	// Build a stable set of rules under tool.driver.rules to reference by index
 	ruleIndex := map[string]int{}
 	for _, f := range findings {
-		if _, ok := ruleIndex[f.Detector]; !ok {
-			ruleIndex[f.Detector] = len(run.Tool.Driver.Rules)
-			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
-				ID:        f.Detector,
-				ShortDesc: &sarifMessage{Text: f.Detector + " detection"},
-				Help:      &sarifMessage{Text: "Secret-like token detected. Review and rotate if valid."},
-				HelpURI:   "https://github.com/varalys/redactyl/blob/main/docs/rules/README.md#" + f.Detector,
-			})
+		idx, ok := ruleIndex[f.Detector]
+		if !ok {
+			idx = len(run.Tool.Driver.Rules)
+			ruleIndex[f.Detector] = idx
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRuleFor(f.Detector))
+		}
+		// A rule's default level and security-severity follow its most
+		// severe result.
+		rule := &run.Tool.Driver.Rules[idx]
+		if rule.DefaultLevel == nil || severityRank(f.Severity) > severityRank(levelSeverity(rule.DefaultLevel.Level)) {
+			rule.DefaultLevel = &sarifRuleConfig{Level: sevToLevel(f.Severity)}
+			rule.Properties["security-severity"] = securitySeverity(f.Severity)
 		}
 	}
+	artifacts := sarifArtifacts{index: map[string]int{}}
+	seen := map[string]bool{}
 	for _, f := range findings {
-		idx := ruleIndex[f.Detector]
-		run.Results = append(run.Results, sarifResult{
-			RuleID:    f.Detector,
-			RuleIndex: idx,
-			Level:     sevToLevel(f.Severity),
-			Message:   sarifMessage{Text: f.Detector + " detected"},
-			Locations: []sarifLoc{{
-				PhysicalLocation: sarifPhys{
-					ArtifactLocation: sarifArt{URI: f.Path},
-					Region:           sarifRegion{StartLine: f.Line, Snippet: &sarifSnippet{Text: f.Match}},
-				},
-			}},
-		})
+		key := FindingKey(f)
+		seen[key] = true
+		res := sarifResult{
+			RuleID:              f.Detector,
+			RuleIndex:           ruleIndex[f.Detector],
+			Level:               sevToLevel(f.Severity),
+			Message:             sarifMessage{Text: f.Detector + " detected"},
+			Locations:           []sarifLoc{{PhysicalLocation: sarifPhys{ArtifactLocation: artifacts.location(f.Path), Region: sarifRegion{StartLine: f.Line, StartColumn: f.Column, Snippet: &sarifSnippet{Text: f.Match}}}}},
+			PartialFingerprints: map[string]string{sarifFingerprintKey: key},
+			Properties:          resultProperties(f),
+		}
+		if opts.Baseline != nil {
+			res.BaselineState = "new"
+			if IsBaselined(f, opts.Baseline.Items) {
+				res.BaselineState = "unchanged"
+				res.Suppressions = []sarifSuppression{{Kind: "external", Justification: "listed in the redactyl baseline"}}
+			}
+		}
+		run.Results = append(run.Results, res)
+	}
+	if opts.Baseline != nil {
+		run.Results = append(run.Results, absentResults(opts.Baseline, seen, &run, ruleIndex, &artifacts)...)
+	}
+	run.Artifacts = artifacts.list
+	run.VersionControlProvenance = versionControlProvenance(findings)
+	if opts.ArtifactStats != nil {
+		run.Properties = map[string]interface{}{"artifactStats": opts.ArtifactStats}
 	}
 	doc := sarif{Version: "2.1.0", Runs: []sarifRun{run}}
 	enc := json.NewEncoder(w)
@@ -120,32 +302,107 @@ func WriteSARIF(w io.Writer, findings []types.Finding) error {
 	return enc.Encode(doc)
 }
 
-// WriteSARIFWithStats writes findings as SARIF and includes artifactStats in the run.properties bag.
-func WriteSARIFWithStats(w io.Writer, findings []types.Finding, artifactStats map[string]int) error {
-	run := sarifRun{Tool: sarifTool{Driver: sarifDriver{Name: "redactyl", Version: time.Now().Format("2006.01.02")}}, Properties: map[string]interface{}{}}
-	// Build rules index
-	ruleIndex := map[string]int{}
-	for _, f := range findings {
-		if _, ok := ruleIndex[f.Detector]; !ok {
-			ruleIndex[f.Detector] = len(run.Tool.Driver.Rules)
-			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: f.Detector, ShortDesc: &sarifMessage{Text: f.Detector + " detection"}})
+func sarifRuleFor(detector string) sarifRule {
+	return sarifRule{
+		ID:        detector,
+		Name:      detector,
+		ShortDesc: &sarifMessage{Text: detector + " detection"},
+		FullDesc:  &sarifMessage{Text: "Secret-like token matched by the " + detector + " detector."},
+		Help: &sarifMessage{
+			Text:     "Secret-like token detected. Review and rotate if valid. Treat any credential that reached a repository or artifact as leaked: revoke it, issue a new one, then remove it from the code or history.",
+			Markdown: "Secret-like token detected by `" + detector + "`. Review and rotate if valid.\n\n1. Revoke the credential and issue a new one.\n2. Remove it from the file, or from history with `redactyl purge`.\n3. If it is a known false positive, add it to the baseline with `redactyl baseline update`.",
+		},
+		HelpURI:    ruleHelpURI(detector),
+		Properties: map[string]interface{}{"tags": []string{"security", "secret"}},
+	}
+}
+
+func levelSeverity(level string) types.Severity {
+	switch level {
+	case "error":
+		return types.SevHigh
+	case "warning":
+		return types.SevMed
+	}
+	return types.SevLow
+}
+
+// absentResults reports baseline entries that no current finding matches,
+// at the rule and location recorded in the baseline. Keys without a recorded
+// entry (baselines written before entries existed) are left out, since code
+// scanning rejects results without a location, as are legacy
+// path|detector|match keys, which contain the secret.
+func absentResults(base *Baseline, seen map[string]bool, run *sarifRun, ruleIndex map[string]int, artifacts *sarifArtifacts) []sarifResult {
+	var keys []string
+	for k, ok := range base.Items {
+		e, known := base.Entries[k]
+		if ok && known && e.Path != "" && e.Detector != "" && !seen[k] && len(k) == 64 && isHexString(k) {
+			keys = append(keys, k)
 		}
 	}
-	for _, f := range findings {
-		idx := ruleIndex[f.Detector]
-		run.Results = append(run.Results, sarifResult{
-			RuleID:    f.Detector,
-			RuleIndex: idx,
-			Level:     sevToLevel(f.Severity),
-			Message:   sarifMessage{Text: f.Detector + " detected"},
-			Locations: []sarifLoc{{PhysicalLocation: sarifPhys{ArtifactLocation: sarifArt{URI: f.Path}, Region: sarifRegion{StartLine: f.Line, Snippet: &sarifSnippet{Text: f.Match}}}}},
+	sort.Strings(keys)
+	out := make([]sarifResult, 0, len(keys))
+	for _, k := range keys {
+		e := base.Entries[k]
+		idx, ok := ruleIndex[e.Detector]
+		if !ok {
+			idx = len(run.Tool.Driver.Rules)
+			ruleIndex[e.Detector] = idx
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRuleFor(e.Detector))
+		}
+		out = append(out, sarifResult{
+			RuleID:              e.Detector,
+			RuleIndex:           idx,
+			Level:               "note",
+			Message:             sarifMessage{Text: "A finding recorded in the baseline is no longer present."},
+			Locations:           []sarifLoc{{PhysicalLocation: sarifPhys{ArtifactLocation: artifacts.location(e.Path), Region: sarifRegion{StartLine: max(e.Line, 1)}}}},
+			PartialFingerprints: map[string]string{sarifFingerprintKey: k},
+			BaselineState:       "absent",
 		})
 	}
-	if artifactStats != nil {
-		run.Properties = map[string]interface{}{"artifactStats": artifactStats}
+	return out
+}
+
+func isHexString(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
 	}
-	doc := sarif{Version: "2.1.0", Runs: []sarifRun{run}}
-	enc := json.NewEncoder(w)
-	enc.SetIndent("", "  ")
-	return enc.Encode(doc)
+	return true
+}
+
+// sarifArtifacts collects run.artifacts for virtual paths.
+type sarifArtifacts struct {
+	list  []sarifArtifact
+	index map[string]int // virtual path prefix -> artifact index
+}
+
+// location returns the artifactLocation for p. Plain paths are used as is.
+// Virtual paths add one artifact per level (archive, image, layer, entry);
+// nested levels use URIs rooted at their parent, as in the SARIF spec's
+// examples, and the location references the innermost artifact.
+func (a *sarifArtifacts) location(p string) sarifArt {
+	segs := virtualSegments(p)
+	if len(segs) == 1 {
+		return sarifArt{URI: p}
+	}
+	var parent *int
+	prefix, uri := "", ""
+	for i, seg := range segs {
+		uri = seg.Name
+		if i > 0 {
+			uri = "/" + strings.TrimPrefix(seg.Name, "/")
+			prefix += "::"
+		}
+		prefix += seg.Name
+		idx, ok := a.index[prefix]
+		if !ok {
+			idx = len(a.list)
+			a.index[prefix] = idx
+			a.list = append(a.list, sarifArtifact{Location: sarifArt{URI: uri}, ParentIndex: parent})
+		}
+		parent = &idx
+	}
+	return sarifArt{URI: uri, Index: parent}
 }
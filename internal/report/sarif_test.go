@@ -4,6 +4,7 @@ package report
 import (
 	"bytes"
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/varalys/redactyl/internal/types"
@@ -100,3 +101,225 @@ func TestWriteSARIF_Golden(t *testing.T) {
 		t.Fatalf("expected snippet present")
 	}
 }
+
+func TestWriteSARIF_VersionControlProvenance(t *testing.T) {
+	meta := map[string]string{
+		"commit":         "abc123",
+		"author":         "Dev",
+		"author_email":   "dev@example.com",
+		"date":           "2025-01-15T10:00:00+02:00",
+		"commit_subject": "add config",
+		"history_status": "removed",
+		"repository":     "https://github.com/acme/app.git",
+	}
+	findings := []types.Finding{
+		{Path: "a.txt", Line: 1, Match: "m", Detector: "github-pat", Severity: types.SevHigh, Metadata: meta},
+		{Path: "b.txt", Line: 2, Match: "n", Detector: "github-pat", Severity: types.SevHigh, Metadata: meta},
+		{Path: "c.txt", Line: 3, Match: "o", Detector: "github-pat", Severity: types.SevHigh},
+	}
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, findings); err != nil {
+		t.Fatalf("WriteSARIF: %v", err)
+	}
+	var doc struct {
+		Runs []struct {
+			VersionControlProvenance []struct {
+				RepositoryURI string            `json:"repositoryUri"`
+				RevisionID    string            `json:"revisionId"`
+				AsOfTimeUTC   string            `json:"asOfTimeUtc"`
+				Properties    map[string]string `json:"properties"`
+			} `json:"versionControlProvenance"`
+			Results []struct {
+				Properties map[string]string `json:"properties"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	vcs := doc.Runs[0].VersionControlProvenance
+	if len(vcs) != 1 {
+		t.Fatalf("expected one provenance entry per commit, got %+v", vcs)
+	}
+	if vcs[0].RepositoryURI != "https://github.com/acme/app.git" || vcs[0].RevisionID != "abc123" || vcs[0].AsOfTimeUTC != "2025-01-15T08:00:00Z" || vcs[0].Properties["author"] != "Dev" {
+		t.Fatalf("unexpected provenance %+v", vcs[0])
+	}
+	props := doc.Runs[0].Results[0].Properties
+	if props["commit"] != "abc123" || props["historyStatus"] != "removed" || props["commitSubject"] != "add config" {
+		t.Fatalf("unexpected result properties %+v", props)
+	}
+	if doc.Runs[0].Results[2].Properties != nil {
+		t.Fatalf("expected no properties for working tree finding, got %+v", doc.Runs[0].Results[2].Properties)
+	}
+}
+
+func TestWriteSARIFWithOptions_FingerprintsAndBaseline(t *testing.T) {
+	kept := types.Finding{Path: "a.txt", Line: 1, Match: "m1", Detector: "github-pat", Severity: types.SevHigh}
+	fresh := types.Finding{Path: "b.txt", Line: 2, Match: "m2", Detector: "github-pat", Severity: types.SevMed}
+	gone := types.Finding{Path: "c.txt", Line: 3, Match: "m3", Detector: "jwt", Severity: types.SevLow}
+	unlocated := types.Finding{Path: "e.txt", Line: 4, Match: "m4", Detector: "jwt"}
+	base := Baseline{Items: map[string]bool{
+		FindingKey(unlocated):   true, // written before entries existed: no location to report
+		LegacyFindingKey(fresh): false,
+		"d.txt|jwt|rawsecret":   true, // legacy keys hold the secret and are never echoed
+	}}
+	base.Add(kept)
+	base.Add(gone)
+	var buf bytes.Buffer
+	if err := WriteSARIFWithOptions(&buf, []types.Finding{kept, fresh}, SARIFOptions{Baseline: &base}); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("rawsecret")) {
+		t.Fatalf("legacy baseline key leaked into SARIF")
+	}
+	var doc struct {
+		Runs []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+				PartialFingerprints map[string]string `json:"partialFingerprints"`
+				BaselineState       string            `json:"baselineState"`
+				Suppressions        []struct {
+					Kind string `json:"kind"`
+				} `json:"suppressions"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	res := doc.Runs[0].Results
+	if len(res) != 3 {
+		t.Fatalf("expected 2 results plus 1 absent, got %+v", res)
+	}
+	if res[0].PartialFingerprints[sarifFingerprintKey] != FindingKey(kept) || res[0].BaselineState != "unchanged" || len(res[0].Suppressions) != 1 {
+		t.Fatalf("unexpected baselined result %+v", res[0])
+	}
+	if res[1].BaselineState != "new" || len(res[1].Suppressions) != 0 {
+		t.Fatalf("unexpected new result %+v", res[1])
+	}
+	absent := res[2]
+	if absent.BaselineState != "absent" || absent.PartialFingerprints[sarifFingerprintKey] != FindingKey(gone) {
+		t.Fatalf("unexpected absent result %+v", absent)
+	}
+	// Code scanning requires a rule and at least one location.
+	rules := doc.Runs[0].Tool.Driver.Rules
+	if absent.RuleID != "jwt" || absent.RuleIndex >= len(rules) || rules[absent.RuleIndex].ID != "jwt" {
+		t.Fatalf("expected absent result to reference the jwt rule, got %+v (rules %+v)", absent, rules)
+	}
+	if len(absent.Locations) != 1 || absent.Locations[0].PhysicalLocation.ArtifactLocation.URI != "c.txt" || absent.Locations[0].PhysicalLocation.Region.StartLine != 3 {
+		t.Fatalf("expected absent result at c.txt:3, got %+v", absent.Locations)
+	}
+
+	// Without a baseline there is no baselineState, but fingerprints remain.
+	buf.Reset()
+	if err := WriteSARIF(&buf, []types.Finding{kept}); err != nil {
+		t.Fatal(err)
+	}
+	doc.Runs = nil
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if r := doc.Runs[0].Results[0]; r.BaselineState != "" || r.PartialFingerprints[sarifFingerprintKey] == "" {
+		t.Fatalf("unexpected result without baseline %+v", r)
+	}
+}
+
+func TestWriteSARIF_NestedArtifactLocations(t *testing.T) {
+	layer := "sha256:" + strings.Repeat("e", 64)
+	fs := []types.Finding{
+		{Path: "chart.tgz::templates/secret.yaml", Line: 4, Match: "m", Detector: "github-pat", Severity: types.SevHigh},
+		{Path: "chart.tgz::templates/other.yaml", Line: 1, Match: "n", Detector: "github-pat", Severity: types.SevLow},
+		{Path: "repo/app:v1::" + layer + "/etc/app.env", Line: 2, Match: "o", Detector: "jwt", Severity: types.SevMed},
+		{Path: "plain.txt", Line: 1, Match: "p", Detector: "jwt", Severity: types.SevLow},
+	}
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, fs); err != nil {
+		t.Fatal(err)
+	}
+	type loc struct {
+		URI   string `json:"uri"`
+		Index *int   `json:"index"`
+	}
+	var doc struct {
+		Runs []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID                   string `json:"id"`
+						Help                 struct{ Text, Markdown string }
+						DefaultConfiguration struct {
+							Level string `json:"level"`
+						} `json:"defaultConfiguration"`
+						Properties map[string]any `json:"properties"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Artifacts []struct {
+				Location    loc  `json:"location"`
+				ParentIndex *int `json:"parentIndex"`
+			} `json:"artifacts"`
+			Results []struct {
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation loc `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	run := doc.Runs[0]
+	// chart.tgz, its two entries, the image, its layer and the layer's file.
+	if len(run.Artifacts) != 6 {
+		t.Fatalf("expected 6 artifacts, got %+v", run.Artifacts)
+	}
+	if run.Artifacts[0].Location.URI != "chart.tgz" || run.Artifacts[0].ParentIndex != nil {
+		t.Fatalf("unexpected root artifact %+v", run.Artifacts[0])
+	}
+	if a := run.Artifacts[1]; a.Location.URI != "/templates/secret.yaml" || a.ParentIndex == nil || *a.ParentIndex != 0 {
+		t.Fatalf("unexpected nested artifact %+v", a)
+	}
+	first := run.Results[0].Locations[0].PhysicalLocation.ArtifactLocation
+	if first.URI != "/templates/secret.yaml" || first.Index == nil || *first.Index != 1 {
+		t.Fatalf("result should reference nested artifact, got %+v", first)
+	}
+	file := run.Artifacts[5]
+	if file.Location.URI != "/etc/app.env" || file.ParentIndex == nil || run.Artifacts[*file.ParentIndex].Location.URI != "/"+layer {
+		t.Fatalf("expected file nested under its layer, got %+v", file)
+	}
+	plain := run.Results[3].Locations[0].PhysicalLocation.ArtifactLocation
+	if plain.URI != "plain.txt" || plain.Index != nil {
+		t.Fatalf("plain paths should stay plain, got %+v", plain)
+	}
+
+	rules := run.Tool.Driver.Rules
+	if len(rules) != 2 || rules[0].Help.Text == "" || rules[0].Help.Markdown == "" {
+		t.Fatalf("expected help text on rules, got %+v", rules)
+	}
+	if rules[0].DefaultConfiguration.Level != "error" || rules[0].Properties["security-severity"] != "8.0" {
+		t.Fatalf("rule should follow its most severe result, got %+v", rules[0])
+	}
+	if rules[1].Properties["security-severity"] != "5.5" {
+		t.Fatalf("unexpected security-severity %+v", rules[1])
+	}
+}
@@ -0,0 +1,456 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/varalys/redactyl/internal/types"
+)
+
+func readSARIF(r io.Reader) (sarif, error) {
+	var doc sarif
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return doc, fmt.Errorf("invalid SARIF: %w", err)
+	}
+	if doc.Version != "" && doc.Version != "2.1.0" {
+		return doc, fmt.Errorf("unsupported SARIF version %q (want 2.1.0)", doc.Version)
+	}
+	return doc, nil
+}
+
+func writeSARIFDoc(w io.Writer, doc sarif) error {
+	if doc.Version == "" {
+		doc.Version = "2.1.0"
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// ReadSARIF returns the findings in a SARIF log. For SARIF written by
+// redactyl this is the exact inverse of WriteSARIF: every finding field is
+// restored from the result, its location and its properties. Results from
+// other tools map ruleId to the detector and level to the severity. Absent
+// results (baseline entries that no longer match) are skipped.
+func ReadSARIF(r io.Reader) ([]types.Finding, error) {
+	doc, err := readSARIF(r)
+	if err != nil {
+		return nil, err
+	}
+	var out []types.Finding
+	for _, run := range doc.Runs {
+		for _, res := range run.Results {
+			if f, ok := findingFromResult(run, res); ok {
+				out = append(out, f)
+			}
+		}
+	}
+	return out, nil
+}
+
+// ReadFindingsJSON reads redactyl finding JSON: the array written by
+// scan --json, or the object written with --json-extended.
+func ReadFindingsJSON(r io.Reader) ([]types.Finding, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var findings []types.Finding
+	if err := json.Unmarshal(data, &findings); err == nil {
+		return findings, nil
+	}
+	var obj struct {
+		Findings []types.Finding `json:"findings"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("invalid findings JSON: %w", err)
+	}
+	return obj.Findings, nil
+}
+
+// ReadFindings reads findings from either SARIF or redactyl finding JSON,
+// telling them apart by content.
+func ReadFindings(r io.Reader) ([]types.Finding, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var probe struct {
+		Runs json.RawMessage `json:"runs"`
+	}
+	if json.Unmarshal(data, &probe) == nil && probe.Runs != nil {
+		return ReadSARIF(bytes.NewReader(data))
+	}
+	return ReadFindingsJSON(bytes.NewReader(data))
+}
+
+func findingFromResult(run sarifRun, res sarifResult) (types.Finding, bool) {
+	if res.BaselineState == "absent" {
+		return types.Finding{}, false
+	}
+	f := types.Finding{Detector: res.RuleID}
+	level := res.Level
+	if rule := ruleFor(run, res); rule != nil {
+		f.Detector = rule.ID
+		if level == "" && rule.DefaultLevel != nil {
+			level = rule.DefaultLevel.Level
+		}
+	}
+	if level == "" {
+		level = "warning" // SARIF's default level
+	}
+	f.Severity = levelSeverity(level)
+	if len(res.Locations) > 0 {
+		phys := res.Locations[0].PhysicalLocation
+		f.Path = phys.ArtifactLocation.URI
+		if f.Path == "" && phys.ArtifactLocation.Index != nil && *phys.ArtifactLocation.Index < len(run.Artifacts) {
+			f.Path = run.Artifacts[*phys.ArtifactLocation.Index].Location.URI
+		}
+		f.Line = phys.Region.StartLine
+		f.Column = phys.Region.StartColumn
+		if phys.Region.Snippet != nil {
+			f.Match = phys.Region.Snippet.Text
+		}
+	}
+	commitKeys := map[string]string{}
+	for _, p := range commitProperties {
+		commitKeys[p.prop] = p.key
+	}
+	for k, raw := range res.Properties {
+		v, ok := raw.(string)
+		if !ok {
+			continue
+		}
+		switch {
+		case commitKeys[k] != "":
+			setMetadata(&f, commitKeys[k], v)
+		case strings.HasPrefix(k, metadataPropPrefix):
+			setMetadata(&f, strings.TrimPrefix(k, metadataPropPrefix), v)
+		case k == "confidence":
+			f.Confidence, _ = strconv.ParseFloat(v, 64)
+		case k == "context":
+			f.Context = v
+		case k == "secret":
+			f.Secret = v
+		case k == "virtualPath":
+			f.Path = v
+		case k == "severity":
+			f.Severity = types.Severity(v)
+		}
+	}
+	return f, true
+}
+
+// ruleFor returns the rule a result refers to by ID or, without one, by index.
+func ruleFor(run sarifRun, res sarifResult) *sarifRule {
+	rules := run.Tool.Driver.Rules
+	if res.RuleID == "" {
+		if res.RuleIndex >= 0 && res.RuleIndex < len(rules) {
+			return &rules[res.RuleIndex]
+		}
+		return nil
+	}
+	for i := range rules {
+		if rules[i].ID == res.RuleID {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+func setMetadata(f *types.Finding, k, v string) {
+	if f.Metadata == nil {
+		f.Metadata = map[string]string{}
+	}
+	f.Metadata[k] = v
+}
+
+// MergeSARIF combines SARIF logs into one. Runs from the same tool become a
+// single run: rules are de-duplicated by ID, artifacts by location and
+// parent, and results by rule, fingerprint and location, with rule and
+// artifact indexes rewritten. Runs from other tools are kept as they are.
+func MergeSARIF(w io.Writer, inputs ...io.Reader) error {
+	var out sarif
+	byTool := map[string]*runMerger{}
+	var order []string
+	for i, in := range inputs {
+		doc, err := readSARIF(in)
+		if err != nil {
+			return fmt.Errorf("input %d: %w", i+1, err)
+		}
+		for _, run := range doc.Runs {
+			name := run.Tool.Driver.Name
+			m, ok := byTool[name]
+			if !ok {
+				m = newRunMerger(run)
+				byTool[name] = m
+				order = append(order, name)
+			}
+			m.add(run)
+		}
+	}
+	for _, name := range order {
+		out.Runs = append(out.Runs, byTool[name].run)
+	}
+	return writeSARIFDoc(w, out)
+}
+
+type runMerger struct {
+	run       sarifRun
+	rules     map[string]int
+	artifacts map[string]int
+	results   map[string]bool
+	vcs       map[string]bool
+}
+
+func newRunMerger(first sarifRun) *runMerger {
+	run := first
+	run.Tool.Driver.Rules = nil
+	run.Artifacts, run.Results, run.VersionControlProvenance, run.Properties = nil, []sarifResult{}, nil, nil
+	return &runMerger{run: run, rules: map[string]int{}, artifacts: map[string]int{}, results: map[string]bool{}, vcs: map[string]bool{}}
+}
+
+func (m *runMerger) add(src sarifRun) {
+	for _, rule := range src.Tool.Driver.Rules {
+		if _, ok := m.rules[rule.ID]; !ok {
+			m.rules[rule.ID] = len(m.run.Tool.Driver.Rules)
+			m.run.Tool.Driver.Rules = append(m.run.Tool.Driver.Rules, rule)
+		}
+	}
+
+	artMap := make([]int, len(src.Artifacts))
+	for i := range artMap {
+		artMap[i] = -1
+	}
+	var mapArtifact func(i int, depth int) int
+	mapArtifact = func(i, depth int) int {
+		if artMap[i] >= 0 || depth > len(src.Artifacts) {
+			return artMap[i]
+		}
+		a := src.Artifacts[i]
+		var parent *int
+		if a.ParentIndex != nil && *a.ParentIndex >= 0 && *a.ParentIndex < len(src.Artifacts) {
+			if p := mapArtifact(*a.ParentIndex, depth+1); p >= 0 {
+				parent = &p
+			}
+		}
+		key := a.Location.URI
+		if parent != nil {
+			key = strconv.Itoa(*parent) + "\x00" + key
+		}
+		idx, ok := m.artifacts[key]
+		if !ok {
+			idx = len(m.run.Artifacts)
+			m.artifacts[key] = idx
+			a.ParentIndex = parent
+			a.Location.Index = nil
+			m.run.Artifacts = append(m.run.Artifacts, a)
+		}
+		artMap[i] = idx
+		return idx
+	}
+	for i := range src.Artifacts {
+		mapArtifact(i, 0)
+	}
+
+	for _, res := range src.Results {
+		if rule := ruleFor(src, res); rule != nil {
+			res.RuleIndex = m.rules[rule.ID]
+		}
+		res.Locations = append([]sarifLoc(nil), res.Locations...)
+		for i := range res.Locations {
+			art := &res.Locations[i].PhysicalLocation.ArtifactLocation
+			if art.Index != nil && *art.Index >= 0 && *art.Index < len(artMap) {
+				idx := artMap[*art.Index]
+				art.Index = &idx
+			}
+		}
+		key := resultKey(res)
+		if m.results[key] {
+			continue
+		}
+		m.results[key] = true
+		m.run.Results = append(m.run.Results, res)
+	}
+
+	for _, v := range src.VersionControlProvenance {
+		if k := v.RepositoryURI + "@" + v.RevisionID; !m.vcs[k] {
+			m.vcs[k] = true
+			m.run.VersionControlProvenance = append(m.run.VersionControlProvenance, v)
+		}
+	}
+	m.mergeProperties(src.Properties)
+}
+
+// mergeProperties keeps the first value of each run property, except
+// artifactStats counters, which are summed.
+func (m *runMerger) mergeProperties(props map[string]interface{}) {
+	for k, v := range props {
+		if m.run.Properties == nil {
+			m.run.Properties = map[string]interface{}{}
+		}
+		cur, ok := m.run.Properties[k]
+		if !ok {
+			m.run.Properties[k] = v
+			continue
+		}
+		if k != "artifactStats" {
+			continue
+		}
+		a, okA := cur.(map[string]interface{})
+		b, okB := v.(map[string]interface{})
+		if !okA || !okB {
+			continue
+		}
+		sum := map[string]interface{}{}
+		for name, n := range a {
+			sum[name] = n
+		}
+		for name, n := range b {
+			x, _ := sum[name].(float64)
+			y, _ := n.(float64)
+			sum[name] = x + y
+		}
+		m.run.Properties[k] = sum
+	}
+}
+
+// resultKey identifies a result for de-duplication.
+func resultKey(res sarifResult) string {
+	fps := make([]string, 0, len(res.PartialFingerprints))
+	for k, v := range res.PartialFingerprints {
+		fps = append(fps, k+"="+v)
+	}
+	sort.Strings(fps)
+	loc := ""
+	if len(res.Locations) > 0 {
+		phys := res.Locations[0].PhysicalLocation
+		loc = phys.ArtifactLocation.URI
+		if phys.ArtifactLocation.Index != nil {
+			loc += "#" + strconv.Itoa(*phys.ArtifactLocation.Index)
+		}
+		loc += ":" + strconv.Itoa(phys.Region.StartLine) + ":" + strconv.Itoa(phys.Region.StartColumn)
+	}
+	return strings.Join([]string{res.RuleID, res.BaselineState, strings.Join(fps, ","), loc, res.Message.Text}, "|")
+}
+
+// SARIFFilter selects results for FilterSARIF.
+type SARIFFilter struct {
+	MinSeverity string   // low, medium or high; empty keeps every severity
+	Paths       []string // glob patterns (doublestar) matched against the path or its base name
+}
+
+// FilterSARIF copies a SARIF log, keeping only results that pass flt. Runs,
+// rules and artifacts are kept as they are, so indexes stay valid. Absent
+// results have no severity or path and are dropped whenever a filter is set.
+func FilterSARIF(w io.Writer, r io.Reader, flt SARIFFilter) error {
+	doc, err := readSARIF(r)
+	if err != nil {
+		return err
+	}
+	for i, run := range doc.Runs {
+		kept := []sarifResult{}
+		for _, res := range run.Results {
+			f, ok := findingFromResult(run, res)
+			if !ok && (flt.MinSeverity != "" || len(flt.Paths) > 0) {
+				continue
+			}
+			if ok && !flt.keep(f) {
+				continue
+			}
+			kept = append(kept, res)
+		}
+		doc.Runs[i].Results = kept
+	}
+	return writeSARIFDoc(w, doc)
+}
+
+func (flt SARIFFilter) keep(f types.Finding) bool {
+	if flt.MinSeverity != "" && !meetsThreshold(f.Severity, flt.MinSeverity) {
+		return false
+	}
+	if len(flt.Paths) == 0 {
+		return true
+	}
+	for _, g := range flt.Paths {
+		if ok, _ := doublestar.Match(g, f.Path); ok {
+			return true
+		}
+		if ok, _ := doublestar.Match(g, path.Base(f.Path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffFindings compares two sets of findings by FindingKey and returns the
+// ones only in next (added) and the ones only in prev (fixed).
+func DiffFindings(prev, next []types.Finding) (added, fixed []types.Finding) {
+	keys := func(fs []types.Finding) map[string]bool {
+		m := make(map[string]bool, len(fs))
+		for _, f := range fs {
+			m[FindingKey(f)] = true
+		}
+		return m
+	}
+	prevKeys, nextKeys := keys(prev), keys(next)
+	seen := map[string]bool{}
+	for _, f := range next {
+		if k := FindingKey(f); !prevKeys[k] && !seen[k] {
+			seen[k] = true
+			added = append(added, f)
+		}
+	}
+	for _, f := range prev {
+		if k := FindingKey(f); !nextKeys[k] && !seen[k] {
+			seen[k] = true
+			fixed = append(fixed, f)
+		}
+	}
+	return added, fixed
+}
+
+// WriteCSV writes findings as CSV with a header row. Matches are masked.
+func WriteCSV(w io.Writer, findings []types.Finding) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"path", "line", "column", "detector", "severity", "confidence", "match", "commit", "fingerprint"})
+	for _, f := range findings {
+		_ = cw.Write([]string{
+			f.Path,
+			strconv.Itoa(f.Line),
+			strconv.Itoa(f.Column),
+			f.Detector,
+			string(f.Severity),
+			strconv.FormatFloat(f.Confidence, 'f', 2, 64),
+			maskValue(f.Match),
+			f.Metadata["commit"],
+			FindingKey(f),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMarkdownTable writes findings as a Markdown table, most severe
+// first. Matches are masked.
+func WriteMarkdownTable(w io.Writer, findings []types.Finding) error {
+	var b strings.Builder
+	writeMarkdownFindings(&b, findings, nil)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell escapes text for a Markdown table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	s = strings.ReplaceAll(s, "`", "'")
+	return strings.Join(strings.Fields(s), " ")
+}
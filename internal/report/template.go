@@ -0,0 +1,220 @@
+package report
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/varalys/redactyl/internal/engine"
+	"github.com/varalys/redactyl/internal/types"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// TemplateOptions controls WriteTemplate.
+type TemplateOptions struct {
+	Target    string       // what was scanned, e.g. a path or repository URL
+	Version   string       // redactyl version
+	Repo      TemplateRepo // repository the scan ran in, when known
+	Generated time.Time    // defaults to now
+	NoColor   bool         // makes colorSeverity return plain text
+}
+
+// TemplateRepo is the repository metadata available to templates.
+type TemplateRepo struct {
+	Name   string
+	Commit string
+	Branch string
+}
+
+// TemplateData is the value templates are executed with. The scan result is
+// embedded, so .Findings, .FilesScanned, .Duration and .ArtifactStats work
+// directly.
+type TemplateData struct {
+	engine.Result
+	Target    string
+	Version   string
+	Repo      TemplateRepo
+	Generated time.Time
+}
+
+// TemplateGroup is a set of findings sharing a key, as returned by the
+// groupBy template functions.
+type TemplateGroup struct {
+	Key      string
+	Findings []types.Finding
+}
+
+// BuiltinTemplateNames lists the templates shipped with redactyl.
+func BuiltinTemplateNames() []string {
+	entries, _ := builtinTemplates.ReadDir("templates")
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".tmpl"); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuiltinTemplate returns the source of a built-in template.
+func BuiltinTemplate(name string) (string, bool) {
+	b, err := builtinTemplates.ReadFile(path.Join("templates", name+".tmpl"))
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+// ValidateTemplate reports syntax errors and unknown functions in a
+// template without rendering it.
+func ValidateTemplate(name, text string) error {
+	if _, err := template.New(name).Funcs(templateFuncs(TemplateOptions{})).Parse(text); err != nil {
+		return fmt.Errorf("template %s: %w", name, err)
+	}
+	return nil
+}
+
+// WriteTemplate renders res through the text/template source text. See
+// templateFuncs for the helper functions available to templates.
+func WriteTemplate(w io.Writer, name, text string, res engine.Result, opts TemplateOptions) error {
+	tmpl, err := template.New(name).Funcs(templateFuncs(opts)).Parse(text)
+	if err != nil {
+		return fmt.Errorf("template %s: %w", name, err)
+	}
+	if opts.Generated.IsZero() {
+		opts.Generated = time.Now()
+	}
+	data := TemplateData{Result: res, Target: opts.Target, Version: opts.Version, Repo: opts.Repo, Generated: opts.Generated}
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("template %s: %w", name, err)
+	}
+	return nil
+}
+
+// templateFuncs are the helpers available to templates:
+//
+//	mask            masked secret value, e.g. ghp_…6789
+//	severityColor   hex colour for a severity (for Slack attachments, HTML)
+//	colorSeverity   severity wrapped in terminal colours (plain with --no-color)
+//	groupByDetector findings grouped by detector, as []TemplateGroup
+//	groupByPath     findings grouped by path
+//	groupBySeverity findings grouped by severity, most severe first
+//	countSeverity   number of findings with a severity
+//	splitPath       levels of a virtual path, each with .Name and .Kind
+//	outerPath       artifact on disk for a virtual path, or the path itself
+//	innerPath       file inside an artifact, or the path itself
+//	remediation     remediation hint for a finding
+//	fingerprint     baseline key of a finding
+//	csv             a quoted CSV field
+//	json            a JSON value
+//	markdown        text escaped for a Markdown table cell
+//	upper, lower, join, add
+func templateFuncs(opts TemplateOptions) template.FuncMap {
+	return template.FuncMap{
+		"mask":          maskValue,
+		"severityColor": func(s any) string { return severityColor(templateSeverity(s)) },
+		"colorSeverity": func(s any) string {
+			if opts.NoColor {
+				return string(templateSeverity(s))
+			}
+			return colorSeverity(templateSeverity(s))
+		},
+		"groupByDetector": func(fs []types.Finding) []TemplateGroup {
+			return groupFindings(fs, func(f types.Finding) string { return f.Detector })
+		},
+		"groupByPath": func(fs []types.Finding) []TemplateGroup {
+			return groupFindings(fs, func(f types.Finding) string { return f.Path })
+		},
+		"groupBySeverity": groupBySeverity,
+		"countSeverity": func(fs []types.Finding, s string) int {
+			n := 0
+			for _, f := range fs {
+				if string(f.Severity) == s {
+					n++
+				}
+			}
+			return n
+		},
+		"splitPath": virtualSegments,
+		"outerPath": func(p string) string { return virtualSegments(p)[0].Name },
+		"innerPath": func(p string) string {
+			segs := virtualSegments(p)
+			return segs[len(segs)-1].Name
+		},
+		"remediation": Remediation,
+		"fingerprint": FindingKey,
+		"csv":         csvField,
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"markdown": markdownCell,
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"join":     strings.Join,
+		"add":      func(a, b int) int { return a + b },
+	}
+}
+
+// templateSeverity accepts a finding's severity or a plain string such as a
+// groupBySeverity key.
+func templateSeverity(v any) types.Severity {
+	return types.Severity(fmt.Sprint(v))
+}
+
+// severityColor returns the colour used for a severity in chat and web
+// formats.
+func severityColor(s types.Severity) string {
+	switch s {
+	case types.SevHigh:
+		return "#d73a49"
+	case types.SevMed:
+		return "#e36209"
+	default:
+		return "#0366d6"
+	}
+}
+
+// groupFindings groups findings by key, sorted by key, keeping the order of
+// findings within each group.
+func groupFindings(fs []types.Finding, key func(types.Finding) string) []TemplateGroup {
+	idx := map[string]int{}
+	var groups []TemplateGroup
+	for _, f := range fs {
+		k := key(f)
+		i, ok := idx[k]
+		if !ok {
+			i = len(groups)
+			idx[k] = i
+			groups = append(groups, TemplateGroup{Key: k})
+		}
+		groups[i].Findings = append(groups[i].Findings, f)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups
+}
+
+func groupBySeverity(fs []types.Finding) []TemplateGroup {
+	groups := groupFindings(fs, func(f types.Finding) string { return string(f.Severity) })
+	sort.SliceStable(groups, func(i, j int) bool {
+		return severityRank(types.Severity(groups[i].Key)) > severityRank(types.Severity(groups[j].Key))
+	})
+	return groups
+}
+
+// csvField quotes s for use as a CSV field when it needs it.
+func csvField(s string) string {
+	if !strings.ContainsAny(s, ",\"\r\n") && strings.TrimSpace(s) == s {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
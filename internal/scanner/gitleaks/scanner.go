@@ -246,9 +246,11 @@ func wrapGitleaksError(err error, stderr string) error {
 
 func normalizeContext(ctx scanner.ScanContext, fallback string) scanner.ScanContext {
 	out := scanner.ScanContext{
-		VirtualPath: ctx.VirtualPath,
-		RealPath:    ctx.RealPath,
-		Metadata:    nil,
+		VirtualPath:  ctx.VirtualPath,
+		RealPath:     ctx.RealPath,
+		Metadata:     nil,
+		LineOffset:   ctx.LineOffset,
+		ColumnOffset: ctx.ColumnOffset,
 	}
 	if out.VirtualPath == "" {
 		out.VirtualPath = fallback
@@ -339,7 +341,7 @@ func (s *Scanner) convertFindings(gf []GitleaksFinding, ctx scanner.ScanContext)
 			Detector:   f.RuleID,
 			Match:      f.Match,
 			Secret:     f.Secret,
-			Line:       f.StartLine,
+			Line:       f.StartLine + ctx.LineOffset,
 			Column:     f.StartColumn,
 			Context:    f.Description,
 			Confidence: confidence,
@@ -347,6 +349,10 @@ func (s *Scanner) convertFindings(gf []GitleaksFinding, ctx scanner.ScanContext)
 			Metadata:   make(map[string]string),
 		}
 
+		if f.StartLine <= 1 {
+			finding.Column += ctx.ColumnOffset
+		}
+
 		for k, v := range ctx.Metadata {
 			finding.Metadata[k] = v
 		}
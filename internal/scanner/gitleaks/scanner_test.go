@@ -205,6 +205,24 @@ func TestScanner_Version(t *testing.T) {
 	assert.NotEqual(t, "unknown", version)
 }
 
+func TestConvertFindings_AppliesFragmentOffsets(t *testing.T) {
+	s := &Scanner{}
+	ctx := scanner.ScanContext{VirtualPath: "terraform.tfstate", LineOffset: 41, ColumnOffset: 12}
+
+	findings := s.convertFindings([]GitleaksFinding{
+		{RuleID: "generic-api-key", StartLine: 1, StartColumn: 3},
+		{RuleID: "generic-api-key", StartLine: 2, StartColumn: 5},
+	}, ctx)
+	require.Len(t, findings, 2)
+
+	// First fragment line shares the source line with the preceding text.
+	assert.Equal(t, 42, findings[0].Line)
+	assert.Equal(t, 15, findings[0].Column)
+	// Later lines start at column 1 of the source.
+	assert.Equal(t, 43, findings[1].Line)
+	assert.Equal(t, 5, findings[1].Column)
+}
+
 func TestMapGitleaksToConfidence(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -46,6 +46,14 @@ type ScanContext struct {
 	//   - "kubernetes_kind": "Secret"
 	//   - "kubernetes_namespace": "default"
 	Metadata map[string]string
+
+	// LineOffset and ColumnOffset locate the scanned data inside a larger
+	// source file when only a fragment of it is scanned (for example a
+	// single value extracted from a Terraform state file). Scanners add
+	// LineOffset to every reported line and ColumnOffset to columns on the
+	// fragment's first line, so findings point at the original file.
+	LineOffset   int
+	ColumnOffset int
 }
 
 // VirtualPathSeparator is used to delimit components in virtual paths.
@@ -483,8 +483,7 @@ func (m Model) addToBaseline() tea.Cmd {
 		base = report.Baseline{Items: map[string]bool{}}
 	}
 
-	key := report.FindingKey(*f)
-	base.Items[key] = true
+	base.Add(*f)
 
 	// Serialize manually since report.SaveBaseline regenerates from []Finding
 	buf, err := json.MarshalIndent(base, "", "  ")
@@ -515,8 +514,7 @@ func (m *Model) removeFromBaseline() tea.Cmd {
 		return func() tea.Msg { return statusMsg(fmt.Sprintf("Error loading baseline: %v", err)) }
 	}
 
-	delete(base.Items, key)
-	delete(base.Items, report.LegacyFindingKey(*f))
+	base.Remove(*f)
 
 	buf, err := json.MarshalIndent(base, "", "  ")
 	if err != nil {
@@ -577,9 +575,8 @@ func (m *Model) bulkBaseline() tea.Cmd {
 	for origIdx := range m.selectedFindings {
 		if origIdx >= 0 && origIdx < len(m.findings) {
 			f := m.findings[origIdx]
-			key := report.FindingKey(f)
-			if !base.Items[key] {
-				base.Items[key] = true
+			if !base.Items[report.FindingKey(f)] {
+				base.Add(f)
 				count++
 			}
 		}
@@ -22,6 +22,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/varalys/redactyl/internal/audit"
+	"github.com/varalys/redactyl/internal/git"
 	"github.com/varalys/redactyl/internal/report"
 	"github.com/varalys/redactyl/internal/types"
 )
@@ -774,6 +775,48 @@ type BlameInfo struct {
 	Commit string
 }
 
+// formatCommit renders commit metadata as "abc12345 (Author <email>, 2025-01-15)".
+func formatCommit(meta map[string]string) string {
+	commit := meta["commit"]
+	if len(commit) > 8 {
+		commit = commit[:8]
+	}
+	author := meta["author"]
+	if email := meta["author_email"]; email != "" {
+		author += " <" + email + ">"
+	}
+	date := meta["date"]
+	if len(date) > 10 {
+		date = date[:10]
+	}
+	if date == "" {
+		return fmt.Sprintf("%s (%s)", commit, author)
+	}
+	return fmt.Sprintf("%s (%s, %s)", commit, author, date)
+}
+
+// historyStatusText explains a history_status value and whether purging
+// history is needed.
+func historyStatusText(status string) string {
+	switch status {
+	case "present":
+		return "still present at HEAD - remove and rotate"
+	case "removed":
+		return "removed at HEAD but still in history - rotate and consider a history purge"
+	case "unmerged":
+		return "only on unmerged branches or tags - rotate and delete the branch"
+	case "uncommitted":
+		return "not committed yet - remove before committing"
+	case "stash":
+		return "only in a stash entry - rotate, drop the stash, then expire reflogs and gc"
+	case "reflog":
+		return "only in a reflog (e.g. after reset --hard) - rotate, then expire reflogs and gc"
+	case "unreachable":
+		return "in an unreachable object - rotate, then run git gc --prune=now"
+	}
+	return status
+}
+
 func getGitBlame(path string, line int) *BlameInfo {
 	if strings.Contains(path, "::") {
 		return nil
@@ -1031,7 +1074,16 @@ func (m *Model) updateViewportContentForFinding(f types.Finding) {
 	}
 
 	isVirtual := strings.Contains(f.Path, "::")
-	if isVirtual {
+	if git.IsMessagePath(f.Path) {
+		source, part, _ := strings.Cut(f.Path, "::")
+		virtualStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("11")).
+			Italic(true)
+		b.WriteString(virtualStyle.Render("GIT METADATA: This finding is in a commit message, tag annotation or note."))
+		b.WriteString("\n\n")
+		b.WriteString(fmt.Sprintf("%s %s\n", keyStyle.Render("Source:"), source))
+		b.WriteString(fmt.Sprintf("%s %s\n", keyStyle.Render("Part:"), part))
+	} else if isVirtual {
 		virtualStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("11")).
 			Italic(true)
@@ -1071,7 +1123,7 @@ func (m *Model) updateViewportContentForFinding(f types.Finding) {
 	}
 	if len(f.Metadata) > 0 {
 		// Filter out commit-related keys (shown separately)
-		skipKeys := map[string]bool{"commit": true, "author": true, "date": true}
+		skipKeys := map[string]bool{"commit": true, "author": true, "date": true, "author_email": true, "commit_subject": true, "history_status": true, "repository": true}
 		var hasDisplayable bool
 		for k := range f.Metadata {
 			if !skipKeys[k] {
@@ -1089,21 +1141,20 @@ func (m *Model) updateViewportContentForFinding(f types.Finding) {
 		}
 	}
 
-	if !isVirtual {
-		commitStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	commitStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	if commit := f.Metadata["commit"]; commit != "" && f.Metadata["author"] != "" {
+		// History and diff findings carry the commit that introduced them.
+		b.WriteString(fmt.Sprintf("%s %s\n", keyStyle.Render("Commit:"), commitStyle.Render(formatCommit(f.Metadata))))
+		if subject := f.Metadata["commit_subject"]; subject != "" {
+			b.WriteString(fmt.Sprintf("%s %s\n", keyStyle.Render("Subject:"), subject))
+		}
+		if status := f.Metadata["history_status"]; status != "" {
+			b.WriteString(fmt.Sprintf("%s %s\n", keyStyle.Render("History:"), historyStatusText(status)))
+		}
+	} else if !isVirtual {
 		if blame := getGitBlame(f.Path, f.Line); blame != nil {
 			commitText := fmt.Sprintf("%s (%s, %s)", blame.Commit, blame.Author, blame.Date)
 			b.WriteString(fmt.Sprintf("%s %s\n", keyStyle.Render("Commit:"), commitStyle.Render(commitText)))
-		} else if f.Metadata != nil {
-			// Fallback to metadata for commit info (used in demo mode)
-			if commit, ok := f.Metadata["commit"]; ok {
-				author := f.Metadata["author"]
-				date := f.Metadata["date"]
-				if author != "" && date != "" {
-					commitText := fmt.Sprintf("%s (%s, %s)", commit, author, date)
-					b.WriteString(fmt.Sprintf("%s %s\n", keyStyle.Render("Commit:"), commitStyle.Render(commitText)))
-				}
-			}
 		}
 	}
 
@@ -1084,3 +1084,25 @@ func TestToggleHideSecrets(t *testing.T) {
 		t.Error("hideSecrets should be true after second toggle")
 	}
 }
+
+func TestFormatCommitAndHistoryStatus(t *testing.T) {
+	meta := map[string]string{
+		"commit":       "0123456789abcdef",
+		"author":       "Dev",
+		"author_email": "dev@example.com",
+		"date":         "2025-01-15T10:00:00Z",
+	}
+	if got := formatCommit(meta); got != "01234567 (Dev <dev@example.com>, 2025-01-15)" {
+		t.Errorf("formatCommit = %q", got)
+	}
+	demo := map[string]string{"commit": "a1b2c3d", "author": "dev@example.com", "date": "2025-01-15"}
+	if got := formatCommit(demo); got != "a1b2c3d (dev@example.com, 2025-01-15)" {
+		t.Errorf("formatCommit(demo) = %q", got)
+	}
+	if got := historyStatusText("removed"); !strings.Contains(got, "history purge") {
+		t.Errorf("historyStatusText(removed) = %q", got)
+	}
+	if got := historyStatusText("other"); got != "other" {
+		t.Errorf("historyStatusText(other) = %q", got)
+	}
+}
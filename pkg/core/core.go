@@ -12,6 +12,7 @@ type Config = engine.Config
 type Finding = types.Finding
 type Result = engine.Result
 type DeepStats = engine.DeepStats
+type ProgressEvent = engine.ProgressEvent
 
 // Scan is the stable entrypoint for other programs.
 func Scan(cfg Config) ([]Finding, error) {
@@ -24,6 +25,44 @@ func ScanWithStats(cfg Config) (Result, error) {
 	return engine.ScanWithStats(cfg)
 }
 
+// ScanStream runs a scan and reports findings while it runs instead of only
+// at the end: onFinding is called once per finding as soon as it is produced,
+// and onProgress (optional) after each scanned file. Both run on the scanning
+// goroutine, so slow callbacks slow the scan. The returned Result holds the
+// same findings and statistics as ScanWithStats.
+func ScanStream(cfg Config, onFinding func(Finding), onProgress func(ProgressEvent)) (Result, error) {
+	cfg.OnFinding, cfg.OnProgress = onFinding, onProgress
+	return engine.ScanWithStats(cfg)
+}
+
+// Event is a value sent by ScanChan: either a finding or a progress update.
+type Event struct {
+	Finding  *Finding
+	Progress *ProgressEvent
+}
+
+// ScanChan runs a scan in the background and sends its findings and progress
+// on the returned channel, which is closed when the scan ends. The scan
+// blocks while the channel is not drained. Call wait after the channel is
+// closed to get the final Result and error.
+func ScanChan(cfg Config) (events <-chan Event, wait func() (Result, error)) {
+	ch := make(chan Event, 64)
+	done := make(chan struct{})
+	var res Result
+	var err error
+	go func() {
+		defer close(done)
+		defer close(ch)
+		res, err = ScanStream(cfg,
+			func(f Finding) { ch <- Event{Finding: &f} },
+			func(p ProgressEvent) { ch <- Event{Progress: &p} })
+	}()
+	return ch, func() (Result, error) {
+		<-done
+		return res, err
+	}
+}
+
 // DetectorIDs returns the list of configured detector IDs.
 // This is exposed for convenience to avoid importing internals directly.
 func DetectorIDs() []string { return engine.DetectorIDs() }
@@ -56,3 +56,22 @@ func ExampleScanWithStats() {
 		fmt.Printf("Warning: %d artifacts timed out\n", result.ArtifactStats.AbortedByTime)
 	}
 }
+
+// ExampleScanStream shows how to handle findings as they are found instead
+// of waiting for the whole scan, e.g. for large container images.
+func ExampleScanStream() {
+	cfg := core.Config{Root: ".", ScanContainers: true}
+
+	result, err := core.ScanStream(cfg,
+		func(f core.Finding) {
+			fmt.Printf("%s:%d %s\n", f.Path, f.Line, f.Detector)
+		},
+		func(p core.ProgressEvent) {
+			fmt.Fprintf(os.Stderr, "\r%d files scanned", p.FilesScanned)
+		})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Scan failed: %v\n", err)
+		return
+	}
+	fmt.Printf("Done: %d findings\n", len(result.Findings))
+}